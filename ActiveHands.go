@@ -0,0 +1,68 @@
+package solv
+
+//handIndexes lists every hand index of a range, the active hands of a player are all of them until a node
+//narrows them down
+var handIndexes = newHandIndexes()
+
+func newHandIndexes() []int {
+	indexes := make([]int, NumCombos)
+	for index := range indexes {
+		indexes[index] = index
+	}
+	return indexes
+}
+
+//activeHands returns the indexes of the hands of player that may need work at the current node. For the opponent
+//these are the hands that can still have reach, for the traverser the hands not blocked by the board, since the
+//regrets of a hand need its utility even when the traverser never plays to the node. Every hand left out has zero
+//reach and zero utility, so skipping it gives the same results as going over the whole range
+func (traversal *Traversal) activeHands(player int) []int {
+	if traversal.active[player] == nil {
+		return handIndexes[:len(traversal.Ranges[player])]
+	}
+	return traversal.active[player]
+}
+
+//reachingHands writes the hands of active that have reach to buffer and returns them
+func reachingHands(buffer *[]int, active []int, reach []float64) []int {
+	hands := (*buffer)[:0]
+	for _, hand := range active {
+		if reach[hand] != 0 {
+			hands = append(hands, hand)
+		}
+	}
+	*buffer = hands
+	return hands
+}
+
+//unblockedHands writes the hands of active that do not hold card to buffer and returns them
+func unblockedHands(buffer *[]int, active []int, cards [][2]int, card int) []int {
+	hands := (*buffer)[:0]
+	for _, hand := range active {
+		if cards[hand][0] != card && cards[hand][1] != card {
+			hands = append(hands, hand)
+		}
+	}
+	*buffer = hands
+	return hands
+}
+
+//dealActive narrows the active hands of a worker traversing the subtree of a dealt card to the hands of
+//traverserActive not holding the card and the hands of opponentActive with reach in opponentReachProb
+func (worker *Traversal) dealActive(level *scratchLevel, card int, traverserActive, opponentActive []int,
+	opponentReachProb []float64) {
+	traverser := worker.Traverser
+	if worker.dense {
+		worker.active[traverser] = traverserActive
+		worker.active[traverser^1] = opponentActive
+		return
+	}
+	worker.active[traverser] = unblockedHands(&level.dealtActive[0], traverserActive, worker.cardIndexes[traverser], card)
+	if worker.simultaneous {
+		//both players update their regrets, so they both need the utility of every hand the board does not block
+		worker.active[traverser^1] = unblockedHands(&level.dealtActive[1], opponentActive,
+			worker.cardIndexes[traverser^1], card)
+		return
+	}
+	worker.active[traverser^1] = reachingHands(&level.dealtActive[1], opponentActive, opponentReachProb)
+}
@@ -0,0 +1,65 @@
+package solv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReachingHands(t *testing.T) {
+	var buffer []int
+	reach := []float64{0.5, 0, 1, 0, 0.25}
+	assert.Equal(t, []int{0, 2, 4}, reachingHands(&buffer, handIndexes[:len(reach)], reach))
+	assert.Equal(t, []int{4}, reachingHands(&buffer, []int{1, 3, 4}, reach))
+}
+
+func TestUnblockedHands(t *testing.T) {
+	var buffer []int
+	cards := [][2]int{{0, 1}, {2, 3}, {1, 5}, {7, 8}}
+	assert.Equal(t, []int{1, 3}, unblockedHands(&buffer, handIndexes[:len(cards)], cards, 1))
+	assert.Equal(t, []int{0, 2}, unblockedHands(&buffer, []int{0, 2, 3}, cards, 8))
+}
+
+//assertSameValues checks the regrets and strategy sums of every game node of two trees are the same
+func assertSameValues(t *testing.T, expected, actual Node) {
+	switch node := expected.(type) {
+	case *GameNode:
+		other := actual.(*GameNode)
+		for hand := 0; hand < node.NumHands(); hand++ {
+			assert.Equal(t, node.Regrets(hand), other.Regrets(hand))
+			assert.Equal(t, node.StrategySums(hand), other.StrategySums(hand))
+		}
+		for index := range node.nextNodes {
+			assertSameValues(t, node.nextNodes[index], other.nextNodes[index])
+		}
+	case *ChanceNode:
+		other := actual.(*ChanceNode)
+		for index := range node.nextNodes {
+			assertSameValues(t, node.nextNodes[index], other.nextNodes[index])
+		}
+	}
+}
+
+func TestSparseTraversalMatchesDense(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Close()
+	denseRoot, denseTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	denseTrav.SetWorkerPool(pool)
+	denseTrav.SetSparse(false)
+	runIterations(denseTrav, 20, denseRoot)
+
+	sparseRoot, sparseTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	sparseTrav.SetWorkerPool(pool)
+	runIterations(sparseTrav, 20, sparseRoot)
+
+	//skipped hands would only have added zeros, so the values are the same to the last bit
+	assertSameValues(t, denseRoot, sparseRoot)
+	_, _, denseExploitability := Exploitability(denseTrav, denseRoot)
+	_, _, sparseExploitability := Exploitability(sparseTrav, sparseRoot)
+	assert.Equal(t, denseExploitability, sparseExploitability)
+}
+
+func BenchmarkCFRIterationTurnDense(b *testing.B) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	traversal.SetSparse(false)
+	benchmarkCFRIteration(b, root, traversal)
+}
@@ -2,15 +2,23 @@ package solv
 
 import (
 	"fmt"
+	"github.com/chehsunliu/poker"
 	//"os"
 )
 
+//AllInShowdownNode is reached when a player calls all in before the river. The utility is the average over all
+//runouts, either from the equity table of the board or by evaluating a ShowdownNode for every runout
 type AllInShowdownNode struct {
 	potSize float64
 	winUtility float64
+	rakeUtility float64
+	board []poker.Card
 	street int
+	//runouts is the number of runouts a pair of hands sees, the runouts overlapping the hands are left out
+	runouts float64
 	nextNodes []*ShowdownNode
 	cache *RiverEvaluationCache
+	table *EquityTable
 }
 
 func NewAllInShowdownNode(potSize float64, street int,
@@ -19,12 +27,29 @@ func NewAllInShowdownNode(potSize float64, street int,
 		potSize: potSize,
 		winUtility: potSize / 2.0,
 		street: street,
+		runouts: float64(cache.variant.pairRunouts(street + 2)),
 		cache: cache,
 	}
 	node.nextNodes = make([]*ShowdownNode, 0)
 	return &node
 }
 
+//setRake takes rake from the pot when the equity table gives the utility, runouts evaluated by ShowdownNodes take
+//the rake of their own pots. board is the board the players are all in on
+func (node *AllInShowdownNode) setRake(rake float64, board []poker.Card) {
+	node.winUtility, node.rakeUtility = rakeUtilities(node.potSize, rake)
+	node.board = board
+}
+
+//tableUtilityInto writes the utility of every hand of player from the equity table to utility, which must be zeroed.
+//The table only counts wins against losses, so trees valued with an ICM evaluate every runout instead
+func (node *AllInShowdownNode) tableUtilityInto(traversal *Traversal, player int, utility, opponentReachProb []float64) {
+	node.table.utilityInto(utility, player, opponentReachProb, node.winUtility)
+	if node.rakeUtility != 0 {
+		opponentReachUtility(traversal, player, node.board, utility, opponentReachProb, node.rakeUtility)
+	}
+}
+
 func (node *AllInShowdownNode) PrintNodeDetails(level int) {
 	for i := 0; i < level; i++ {
 		fmt.Print("\t")
@@ -34,33 +59,49 @@ func (node *AllInShowdownNode) PrintNodeDetails(level int) {
 
 //TODO: check that it is unneeded to zero opp reach prob if overlap
 func (node *AllInShowdownNode) CFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
-	utility := make([]float64, len(traverserReachProb))
-	for _, next := range node.nextNodes {
-		newReach := make([]float64, len(opponentReachProb))
+	level := traversal.level()
+	utility := level.utility(traversal.utilitySize())
+	if node.table != nil && traversal.simultaneous {
+		reach := [2][]float64{traverserReachProb, opponentReachProb}
+		for player := range reach {
+			node.tableUtilityInto(traversal, player, traversal.playerUtility(utility, player), reach[player^1])
+		}
+		return utility
+	} else if node.table != nil {
+		node.tableUtilityInto(traversal, traversal.Traverser, utility, opponentReachProb)
+		return utility
+	}
+	traversal.depth++
+	runouts := len(node.nextNodes)
+	//with chance sampling each sampled runout stands for len(nextNodes) / len(sampled) runouts
+	weight := 1.0
+	var sampled []int
+	if traversal.sampling != nil {
+		sampled = traversal.sample(&level.sampled, len(node.nextNodes), boardMask(node.nextNodes[0].board))
+		runouts = len(sampled)
+		weight = float64(len(node.nextNodes)) / float64(len(sampled))
+	}
+	for runout := 0; runout < runouts; runout++ {
+		next := node.nextNodes[runout]
+		if sampled != nil {
+			next = node.nextNodes[sampled[runout]]
+		}
+		newReach := level.reach(len(opponentReachProb))
 		for index, hand := range traversal.Ranges[traversal.Traverser ^ 1] {
-			if !CheckHandBoardOverlap(hand.Hand, next.board) {
+			if CheckHandBoardOverlap(hand.Hand, next.board) {
+				newReach[index] = 0
+			} else {
 				newReach[index] = opponentReachProb[index]
 			}
 		}
 		runoutEV := next.CFRTraversal(traversal, traverserReachProb, newReach)
-		if node.street == 1 {
-			for i := range runoutEV {
-				utility[i] += runoutEV[i] * 2
-			}
-		} else {
-			for i := range runoutEV {
-				utility[i] += runoutEV[i]
-			}
+		for i := range runoutEV {
+			utility[i] += runoutEV[i] * weight
 		}
 	}
-	if node.street == 1 {
-		for i := range utility {
-			utility[i] /= 1980.0
-		}
-	} else {
-		for i := range utility {
-			utility[i] /= 44.0
-		}
+	traversal.depth--
+	for i := range utility {
+		utility[i] /= node.runouts
 	}
 	return utility
 }
@@ -70,6 +111,11 @@ func (node *AllInShowdownNode) AddNextNode(next *ShowdownNode) {
 }
 
 func (node *AllInShowdownNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
+	if node.table != nil {
+		utility := make([]float64, len(traversal.Ranges[traversal.Traverser]))
+		node.tableUtilityInto(traversal, traversal.Traverser, utility, opponentReachProb)
+		return utility
+	}
 	utility := make([]float64, len(traversal.Ranges[traversal.Traverser]))
 	for _, next := range node.nextNodes {
 		newReach := make([]float64, len(opponentReachProb))
@@ -79,25 +125,13 @@ func (node *AllInShowdownNode) BestResponse(traversal *Traversal, opponentReachP
 			}
 		}
 		nodeUtility := next.BestResponse(traversal, newReach)
-		if node.street == 1 {
-			for i := range utility {
-				utility[i] += nodeUtility[i] * 2
-			}
-		} else {
-			for i := range utility {
-				utility[i] += nodeUtility[i]
-			}
+		for i := range utility {
+			utility[i] += nodeUtility[i]
 		}
 	}
 
-	if node.street == 1 {
-		for i := range utility {
-			utility[i] /= 1980.0
-		}
-	} else {
-		for i := range utility {
-			utility[i] /= 44.0
-		}
+	for i := range utility {
+		utility[i] /= node.runouts
 	}
 	return utility
 }
\ No newline at end of file
@@ -0,0 +1,154 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"math"
+	"sort"
+)
+
+//CardAbstraction puts the turn or river cards dealt on a board into buckets. The cards of a bucket keep their own
+//subtrees, so showdowns and best responses are exact, but the game nodes of the street they start share the
+//regrets and strategies of the subtree of the lowest card of the bucket. This saves the memory of every other
+//subtree of the street at the cost of playing the same strategy on every card of a bucket
+type CardAbstraction interface {
+	//Buckets returns the bucket of every card of cards dealt on board, cards with the same bucket share strategies.
+	//ranks ranks the river boards for the hands of the ranges
+	Buckets(board, cards []poker.Card, ranges [2]Range, ranks *RankCache) []int
+}
+
+//TextureAbstraction buckets the cards by the texture they give the board: the board rank they pair, the number of
+//board ranks above them, the number of cards of their suit on the board and the number of board ranks close
+//enough to make a straight with them. Cards that are the same up to a suit permutation of the board always share
+//a bucket
+type TextureAbstraction struct{}
+
+func (TextureAbstraction) Buckets(board, cards []poker.Card, ranges [2]Range, ranks *RankCache) []int {
+	buckets := make([]int, len(cards))
+	for index, card := range cards {
+		buckets[index] = textureBucket(board, card)
+	}
+	return buckets
+}
+
+func textureBucket(board []poker.Card, card poker.Card) int {
+	var boardRanks [13]bool
+	suited := 1
+	for _, boardCard := range board {
+		boardRanks[boardCard.Rank()] = true
+		if boardCard.Suit() == card.Suit() {
+			suited++
+		}
+	}
+	rank := int(card.Rank())
+	paired, above, connected := 0, 0, 0
+	for boardRank := 12; boardRank >= 0; boardRank-- {
+		if !boardRanks[boardRank] {
+			continue
+		}
+		if boardRank > rank {
+			above++
+		}
+		if boardRank == rank {
+			paired = above + 1
+		} else if straightDistance(boardRank, rank) <= 4 {
+			connected++
+		}
+	}
+	return paired + 8*(above+8*(suited+8*connected))
+}
+
+//straightDistance returns the difference of two ranks, counting the ace as a one as well
+func straightDistance(first, second int) int {
+	distance := first - second
+	if distance < 0 {
+		distance = -distance
+	}
+	if first == 12 || second == 12 {
+		low := first + second - 12 + 1
+		if low < distance {
+			distance = low
+		}
+	}
+	return distance
+}
+
+//EquityAbstraction buckets the cards by the equity of the oop range against the ip range on the board with the
+//card, averaged over the rivers when the card is the turn. The equity range from 0 to 1 is cut into the given
+//number of buckets of the same width. The cards the hands of both ranges block of each other are not removed
+type EquityAbstraction struct {
+	NumBuckets int
+}
+
+func (abstraction EquityAbstraction) Buckets(board, cards []poker.Card, ranges [2]Range, ranks *RankCache) []int {
+	buckets := make([]int, len(cards))
+	for index, card := range cards {
+		next := make([]poker.Card, len(board), len(board)+1)
+		copy(next, board)
+		equity := boardEquity(append(next, card), cards, ranges, ranks)
+		buckets[index] = int(math.Min(equity*float64(abstraction.NumBuckets), float64(abstraction.NumBuckets-1)))
+	}
+	return buckets
+}
+
+//boardEquity returns the equity of the oop range against the ip range, the average over every river from cards
+//that is not on the board if board is a turn board
+func boardEquity(board, cards []poker.Card, ranges [2]Range, ranks *RankCache) float64 {
+	if len(board) == 5 {
+		return riverEquity(ranks.Ranks(board), ranges)
+	}
+	equity := 0.0
+	rivers := 0
+	river := make([]poker.Card, len(board)+1)
+	copy(river, board)
+	for _, card := range cards {
+		if checkCardBoardOverlap(card, board) {
+			continue
+		}
+		river[len(board)] = card
+		equity += riverEquity(ranks.Ranks(river), ranges)
+		rivers++
+	}
+	return equity / float64(rivers)
+}
+
+//riverEquity returns the equity of the oop range against the ip range with the ranks of a river board, hands
+//overlapping the board are left out. Ties count as half a win
+func riverEquity(ranks []int16, ranges [2]Range) float64 {
+	type rankWeight struct {
+		rank   int16
+		weight float64
+	}
+	ip := make([]rankWeight, 0, len(ranges[1]))
+	for _, combo := range ranges[1] {
+		if rank := ranks[combo.Hand.ComboIndex()]; rank != 0 {
+			ip = append(ip, rankWeight{rank, combo.Combos})
+		}
+	}
+	sort.Slice(ip, func(i, j int) bool {
+		return ip[i].rank < ip[j].rank
+	})
+	//better[i] is the weight of the ip hands ranked before ip[i]
+	better := make([]float64, len(ip)+1)
+	for i := range ip {
+		better[i+1] = better[i] + ip[i].weight
+	}
+	ipWeight := better[len(ip)]
+
+	wins, oopWeight := 0.0, 0.0
+	for _, combo := range ranges[0] {
+		rank := ranks[combo.Hand.ComboIndex()]
+		if rank == 0 {
+			continue
+		}
+		first := sort.Search(len(ip), func(i int) bool { return ip[i].rank >= rank })
+		last := sort.Search(len(ip), func(i int) bool { return ip[i].rank > rank })
+		beaten := ipWeight - better[last]
+		tied := better[last] - better[first]
+		wins += combo.Combos * (beaten + tied/2)
+		oopWeight += combo.Combos
+	}
+	if oopWeight == 0 || ipWeight == 0 {
+		return 0.5
+	}
+	return wins / oopWeight / ipWeight
+}
@@ -0,0 +1,182 @@
+package solv
+
+import (
+	"bytes"
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+//cardAbstraction puts every card in a bucket of its own
+type cardAbstraction struct{}
+
+func (cardAbstraction) Buckets(board, cards []poker.Card, ranges [2]Range, ranks *RankCache) []int {
+	buckets := make([]int, len(cards))
+	for index := range buckets {
+		buckets[index] = index
+	}
+	return buckets
+}
+
+func TestTextureBuckets(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s")}
+	bucket := func(card string) int {
+		return textureBucket(board, poker.NewCard(card))
+	}
+	//diamonds, hearts and spades are each on the board once
+	assert.Equal(t, bucket("Ad"), bucket("Ah"))
+	assert.Equal(t, bucket("Ad"), bucket("As"))
+	assert.NotEqual(t, bucket("Ad"), bucket("Ac"))
+	assert.Equal(t, bucket("Qh"), bucket("Jh"))
+	assert.Equal(t, bucket("Qh"), bucket("Td"))
+	assert.NotEqual(t, bucket("Qh"), bucket("8h"))
+	assert.NotEqual(t, bucket("Kh"), bucket("9d"))
+	assert.NotEqual(t, bucket("Kh"), bucket("Kc"))
+	assert.NotEqual(t, bucket("Ah"), bucket("Kh"))
+	//the ace makes a straight with the four as well
+	assert.NotEqual(t, bucket("3h"), bucket("Ah"))
+	assert.Equal(t, 1, straightDistance(0, 12))
+	assert.Equal(t, 4, straightDistance(12, 8))
+}
+
+func TestRiverEquity(t *testing.T) {
+	board := []poker.Card{poker.NewCard("2c"), poker.NewCard("3d"), poker.NewCard("7h"), poker.NewCard("8s"),
+		poker.NewCard("Tc")}
+	ranks := NewRankCache(nil).Ranks(board)
+	aces := RemoveConflicts(HandsStringToHandRange("AA"), board)
+	kings := RemoveConflicts(HandsStringToHandRange("KK"), board)
+	assert.Equal(t, 1.0, riverEquity(ranks, [2]Range{aces, kings}))
+	assert.Equal(t, 0.0, riverEquity(ranks, [2]Range{kings, aces}))
+	assert.Equal(t, 0.5, riverEquity(ranks, [2]Range{aces, aces}))
+	//aces win and kings tie against kings
+	assert.InDelta(t, 0.75, riverEquity(ranks, [2]Range{HandsStringToHandRange("AA, KK"), kings}), 1e-9)
+	assert.Equal(t, 0.5, riverEquity(ranks, [2]Range{aces, nil}))
+}
+
+func TestEquityBuckets(t *testing.T) {
+	ranges := [2]Range{RemoveConflicts(HandsStringToHandRange("KK, 99"), turnBoard),
+		RemoveConflicts(HandsStringToHandRange("AA, 44"), turnBoard)}
+	cards := Holdem.nextCards(turnBoard)
+	buckets := EquityAbstraction{NumBuckets: 4}.Buckets(turnBoard, cards, ranges, NewRankCache(nil))
+	for index, card := range cards {
+		assert.True(t, buckets[index] >= 0 && buckets[index] < 4)
+		//sets are ahead of aces on every river but an ace, which puts the aces ahead of every hand but the set of
+		//fours
+		if card.Rank() == 12 {
+			assert.Less(t, buckets[index], buckets[0])
+		}
+	}
+	single := EquityAbstraction{NumBuckets: 1}.Buckets(turnBoard, cards, ranges, NewRankCache(nil))
+	assert.Equal(t, make([]int, len(cards)), single)
+}
+
+func TestAbstractedCardsShareStrategies(t *testing.T) {
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetCardAbstraction(TextureAbstraction{})
+	root, _ := newTurnTestTree(params)
+	//check, check deals the river
+	next, err := NodeAtPath(root, []int{0, 0})
+	assert.Nil(t, err)
+	chance := next.(*ChanceNode)
+	assert.NotEmpty(t, chance.buckets)
+	for _, cards := range chance.buckets {
+		first := chance.nextNodes[cards[0]].(*GameNode)
+		for _, index := range cards[1:] {
+			shared := chance.nextNodes[index].(*GameNode)
+			assert.False(t, first == shared)
+			assert.Same(t, &first.Regrets(0)[0], &shared.Regrets(0)[0])
+			assert.Same(t, &first.GetNext(0).(*GameNode).Regrets(0)[0], &shared.GetNext(0).(*GameNode).Regrets(0)[0])
+		}
+	}
+	other := chance.nextNodes[chance.buckets[1][0]].(*GameNode)
+	assert.False(t, &chance.nextNodes[chance.buckets[0][0]].(*GameNode).Regrets(0)[0] == &other.Regrets(0)[0])
+
+	count := func(root *GameNode) int {
+		nodes := 0
+		walkGameNodes(root, func(node *GameNode) {
+			nodes++
+		})
+		return nodes
+	}
+	exact, _ := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	assert.Less(t, count(root), count(exact))
+}
+
+func TestAbstractionWithSingleCardBucketsIsExact(t *testing.T) {
+	expected, expectedTraversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetCardAbstraction(cardAbstraction{})
+	root, traversal := newTurnTestTree(params)
+	runIterations(expectedTraversal, 10, expected)
+	runIterations(traversal, 10, root)
+	assertSameValues(t, expected, root)
+}
+
+func TestBucketIsUpdatedOncePerIteration(t *testing.T) {
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetCardAbstraction(TextureAbstraction{})
+	root, traversal := newTurnTestTree(params)
+	exact, exactTraversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	//the first iteration discounts positive and negative regrets alike, so the update of a bucket is the sum of the
+	//discounted updates of its cards
+	for _, trav := range []*Traversal{traversal, exactTraversal} {
+		trav.Iteration = 1
+		trav.Traverser = 0
+	}
+	root.CFRTraversal(traversal, convertRangeToFloatSlice(traversal.Ranges[0]),
+		convertRangeToFloatSlice(traversal.Ranges[1]))
+	exact.CFRTraversal(exactTraversal, convertRangeToFloatSlice(exactTraversal.Ranges[0]),
+		convertRangeToFloatSlice(exactTraversal.Ranges[1]))
+
+	next, err := NodeAtPath(root, []int{0, 0})
+	assert.Nil(t, err)
+	chance := next.(*ChanceNode)
+	exactNext, err := NodeAtPath(exact, []int{0, 0})
+	assert.Nil(t, err)
+	exactChance := exactNext.(*ChanceNode)
+	for _, cards := range chance.buckets {
+		shared := chance.nextNodes[cards[0]].(*GameNode)
+		for hand := 0; hand < shared.NumHands(); hand++ {
+			regrets := make([]float64, shared.NumActions())
+			strategySums := make([]float64, shared.NumActions())
+			for _, index := range cards {
+				card := exactChance.nextNodes[index].(*GameNode)
+				for action := range regrets {
+					regrets[action] += card.Regrets(hand)[action]
+					strategySums[action] += card.StrategySums(hand)[action]
+				}
+			}
+			assert.InDeltaSlice(t, regrets, shared.Regrets(hand), 1e-9)
+			assert.InDeltaSlice(t, strategySums, shared.StrategySums(hand), 1e-9)
+		}
+	}
+}
+
+func TestAbstractedTreeConverges(t *testing.T) {
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetCardAbstraction(TextureAbstraction{})
+	root, traversal := newTurnTestTree(params)
+	_, _, start := Exploitability(traversal, root)
+	runIterations(traversal, 100, root)
+	_, _, abstracted := Exploitability(traversal, root)
+
+	exact, exactTraversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	runIterations(exactTraversal, 100, exact)
+	_, _, exploitability := Exploitability(exactTraversal, exact)
+	assert.Less(t, abstracted, start/10)
+	//the best response is computed on every card, so it finds the error of the abstraction
+	assert.Greater(t, abstracted, exploitability)
+}
+
+func TestSaveAndLoadAbstractedSolution(t *testing.T) {
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetCardAbstraction(TextureAbstraction{})
+	root, traversal := newTurnTestTree(params)
+	runIterations(traversal, 5, root)
+	var buffer bytes.Buffer
+	assert.Nil(t, SaveSolution(&buffer, root))
+
+	loaded, _ := newTurnTestTree(params)
+	assert.Nil(t, LoadSolution(bytes.NewReader(buffer.Bytes()), loaded))
+	assertSameValues(t, root, loaded)
+}
@@ -21,6 +21,37 @@ func NewCombo(hand Hand, combos float64) *Combo {
 	return &Combo{Hand: hand, Combos: combos}
 }
 
+//NumCombos is the number of distinct two card hands of a 52 card deck
+const NumCombos = 52 * 51 / 2
+
+//ComboIndex returns the canonical index of the hand between 0 and NumCombos-1, the same for either order of
+//the two cards
+func (h Hand) ComboIndex() int {
+	first := cardTo52Int(h[0])
+	second := cardTo52Int(h[1])
+	if first < second {
+		first, second = second, first
+	}
+	return first*(first-1)/2 + second
+}
+
+//CardIndexes returns the index between 0 and 51 of both cards of the hand
+func (h Hand) CardIndexes() [2]int {
+	return [2]int{cardTo52Int(h[0]), cardTo52Int(h[1])}
+}
+
+//ComboIndexes maps the ComboIndex of every hand to its index in the range, or -1 for hands not in the range
+func (rng Range) ComboIndexes() []int {
+	indexes := make([]int, NumCombos)
+	for combo := range indexes {
+		indexes[combo] = -1
+	}
+	for index := range rng {
+		indexes[rng[index].Hand.ComboIndex()] = index
+	}
+	return indexes
+}
+
 func (h Hand) String() string {
 	return h[0].String() + h[1].String()
 }
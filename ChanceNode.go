@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"github.com/chehsunliu/poker"
 	"sync"
-
-	//"sync"
 )
 
 //ChanceNode - important notes are the fact that nextCards[i] tells you which card nextNodes[i] represents,
@@ -17,22 +15,26 @@ type ChanceNode struct {
 
 	nextCards []poker.Card
 	street int
+	//isomorphs[i] is nil if nextNodes[i] is the subtree of nextCards[i] alone, otherwise the card is isomorphic
+	//to a canonical card and nextNodes[i] is the subtree of the canonical card, or a copy of it once the reach
+	//probabilities of the card stop being symmetric to those of the canonical card
+	isomorphs []*cardIsomorphism
+	isomorphGroups []isomorphGroup
+	//buckets lists the cards of every bucket of a CardAbstraction with more than one card, the first card of a
+	//bucket holds the strategies shared by its subtrees. bucketOf[i] is the bucket of nextCards[i], or -1
+	buckets [][]int
+	bucketOf []int
 
 	nextNodes []Node
 }
 
-func NewChanceNode(potSize, stacks float64, board []poker.Card, street int) *ChanceNode {
-	var next []poker.Card
-	if street == 1 {
-		next = constructPossibleNextCards(board, 49)
-	} else {
-		next = constructPossibleNextCards(board, 48)
-	}
+//NewChanceNode constructs a ChanceNode dealing every card of the deck of variant that is not on board
+func NewChanceNode(potSize, stacks float64, board []poker.Card, street int, variant Variant) *ChanceNode {
 	node := ChanceNode{
 		potSize: potSize,
 		ipPlayerStack: stacks,
 		oopPlayerStack: stacks,
-		nextCards: next,
+		nextCards: variant.nextCards(board),
 		street: street,
 	}
 	return &node
@@ -42,6 +44,16 @@ func (node *ChanceNode) AddNextNode(next Node) {
 	node.nextNodes = append(node.nextNodes, next)
 }
 
+//CardIndex returns the index of the child dealing card, or -1 if the card cannot be dealt here
+func (node *ChanceNode) CardIndex(card poker.Card) int {
+	for index := range node.nextCards {
+		if node.nextCards[index] == card {
+			return index
+		}
+	}
+	return -1
+}
+
 func (node *ChanceNode) PrintNodeDetails(level int) {
 	for i := 0; i < level; i++ {
 		fmt.Print("\t")
@@ -51,126 +63,407 @@ func (node *ChanceNode) PrintNodeDetails(level int) {
 }
 
 func (node *ChanceNode) CFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
-	subResults := make([][]float64, len(node.nextCards))
-	result := make([]float64, len(traverserReachProb))
-	oppHands := traversal.Ranges[traversal.Traverser ^ 1]
-	travHands :=  traversal.Ranges[traversal.Traverser]
-
-	var wg sync.WaitGroup
-	wg.Add(len(node.nextNodes))
-	for index, next := range node.nextNodes {
-		go func(i int, nextNode Node) {
-			defer wg.Done()
-			nextTrav := make([]float64, len(traverserReachProb))
-			nextOpp := make([]float64, len(opponentReachProb))
-			for hand := range nextTrav {
-				if !(travHands[hand].Hand[0] == node.nextCards[i] || travHands[hand].Hand[1] == node.nextCards[i]) {
-					nextTrav[hand] = traverserReachProb[hand]
-				}
-			}
-			for hand := range nextOpp {
-				if !(oppHands[hand].Hand[0] == node.nextCards[i] || oppHands[hand].Hand[1] == node.nextCards[i]) {
-					nextOpp[hand] = opponentReachProb[hand]
-				}
-			}
-
-			subResults[i] = nextNode.CFRTraversal(traversal, nextTrav, nextOpp)
-		}(index, next)
+	if traversal.sampling != nil {
+		return node.sampledCFRTraversal(traversal, traverserReachProb, opponentReachProb)
 	}
-	wg.Wait()
+	subResults := node.dealCards(traversal, traverserReachProb, opponentReachProb, false)
+	result := traversal.level().utility(traversal.utilitySize())
 
-/* //this is the serial version
-	for i, next := range node.nextNodes {
-		nextTrav := make([]float64, len(traverserReachProb))
-		nextOpp := make([]float64, len(opponentReachProb))
-		for hand := range nextTrav {
-			if !(travHands[hand].Hand[0] == node.nextCards[i] || travHands[hand].Hand[1] == node.nextCards[i]) {
-				nextTrav[hand] = traverserReachProb[hand]
-			}
-		}
-		for hand := range nextOpp {
-			if !(oppHands[hand].Hand[0] == node.nextCards[i] || oppHands[hand].Hand[1] == node.nextCards[i]) {
-				nextOpp[hand] = opponentReachProb[hand]
-			}
+	for index := range subResults {
+		for hand := range result {
+			result[hand] += subResults[index][hand]
 		}
-		subResults[i] = next.CFRTraversal(traversal, nextTrav, nextOpp)
-	} */
+	}
+	dealt := node.dealtCards()
+	for hand := range result {
+		result[hand] /= dealt
+	}
+	return result
+}
+
+//dealtCards returns the number of cards that can be dealt to a pair of hands, the cards left in the deck without
+//the four cards the hands hold
+func (node *ChanceNode) dealtCards() float64 {
+	return float64(len(node.nextCards) - 4)
+}
+
+func (node *ChanceNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
+	result := make([]float64, len(traversal.Ranges[traversal.Traverser]))
+	subResults := node.dealCards(traversal, nil, opponentReachProb, true)
 
 	for index := range subResults {
 		for hand := range result {
 			result[hand] += subResults[index][hand]
 		}
 	}
-	//This is because board has 4 cards, we have 2, opponent has 2, thus 52-4-2-2 = 44 is the number of
-	//actual possible cards in the deck
-	if node.street == 1 {
-		for hand := range result {
-			result[hand] /= 45.0
+
+	dealt := node.dealtCards()
+	for hand := range result {
+		result[hand] /= dealt
+	}
+	return result
+}
+
+//isomorphGroup lists the isomorphic cards sharing the subtree of a canonical card
+type isomorphGroup struct {
+	canonical int
+	indexes   []int
+}
+
+//setIsomorphs sets the isomorphisms of the dealt cards and groups the isomorphic cards by their canonical card
+func (node *ChanceNode) setIsomorphs(isomorphs []*cardIsomorphism) {
+	node.isomorphs = isomorphs
+	node.isomorphGroups = nil
+	for index := range isomorphs {
+		if isomorphs[index] == nil {
+			continue
 		}
-	} else {
-		for hand := range result {
-			result[hand] /= 44.0
+		canonical := isomorphs[index].canonical
+		found := false
+		for group := range node.isomorphGroups {
+			if node.isomorphGroups[group].canonical == canonical {
+				node.isomorphGroups[group].indexes = append(node.isomorphGroups[group].indexes, index)
+				found = true
+			}
+		}
+		if !found {
+			node.isomorphGroups = append(node.isomorphGroups, isomorphGroup{canonical, []int{index}})
 		}
 	}
-	return result
 }
 
-func (node *ChanceNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
-	result := make([]float64, len(traversal.Ranges[traversal.Traverser]))
-	oppHands := traversal.Ranges[traversal.Traverser ^ 1]
-
-	/* //This is the serial version
-	for index, next := range node.nextNodes {
-		nextOppReach := make([]float64, len(opponentReachProb))
-		for hand := range opponentReachProb {
-			if !(oppHands[hand].Hand[0] == node.nextCards[index] || oppHands[hand].Hand[1] == node.nextCards[index]) {
-				nextOppReach[hand] = opponentReachProb[hand]
+//setBuckets groups the dealt cards by their bucket of a CardAbstraction, cards alone in their bucket keep their
+//own strategies
+func (node *ChanceNode) setBuckets(buckets []int) {
+	node.buckets = nil
+	node.bucketOf = make([]int, len(buckets))
+	first := make(map[int]int)
+	for index, bucket := range buckets {
+		node.bucketOf[index] = -1
+		if _, ok := first[bucket]; !ok {
+			first[bucket] = index
+		}
+	}
+	for index, bucket := range buckets {
+		if first[bucket] != index {
+			continue
+		}
+		var cards []int
+		for card := index; card < len(buckets); card++ {
+			if buckets[card] == bucket {
+				cards = append(cards, card)
 			}
 		}
-		ev := next.BestResponse(traversal, nextOppReach)
-		for hand := range result {
-			result[hand] += ev[hand]
+		if len(cards) < 2 {
+			continue
+		}
+		for _, card := range cards {
+			node.bucketOf[card] = len(node.buckets)
 		}
+		node.buckets = append(node.buckets, cards)
 	}
-	*/
+}
 
+//bucketCards returns the cards of the bucket of the card with the given index, or nil if it has its own strategies
+func (node *ChanceNode) bucketCards(index int) []int {
+	if node.bucketOf == nil || node.bucketOf[index] < 0 {
+		return nil
+	}
+	return node.buckets[node.bucketOf[index]]
+}
 
-	subResults := make([][]float64, len(node.nextCards))
-	var wg sync.WaitGroup
-	wg.Add(len(node.nextNodes))
-	for index, next := range node.nextNodes {
-		go func(i int, nextNode Node) {
-			defer wg.Done()
-			nextOpp := make([]float64, len(opponentReachProb))
+//sharesValues returns if the subtree of the card with the given index uses the strategies of another card
+func (node *ChanceNode) sharesValues(index int) bool {
+	cards := node.bucketCards(index)
+	return cards != nil && cards[0] != index
+}
 
-			for hand := range nextOpp {
-				if !(oppHands[hand].Hand[0] == node.nextCards[i] || oppHands[hand].Hand[1] == node.nextCards[i]) {
-					nextOpp[hand] = opponentReachProb[hand]
-				}
-			}
-			subResults[i] = nextNode.BestResponse(traversal, nextOpp)
-		}(index, next)
+//dealCards traverses the subtree of every dealt card on the worker pool of the traversal with the reach probabilities of hands blocked by
+//the card set to zero, and returns the result of each card in the scratch buffers of the traversal.
+//traverserReachProb is nil for a best response. Every card is traversed by a forked traversal with its own
+//scratch buffers. Cards that are isomorphic to a canonical card are only traversed when the reach probabilities
+//are not symmetric under their suit permutation, otherwise the result of the canonical card is reused with the
+//hands permuted. The cards of a bucket update the same strategies, so they are traversed one after another by one
+//task, except for a best response which only reads the strategies
+func (node *ChanceNode) dealCards(traversal *Traversal, traverserReachProb, opponentReachProb []float64,
+	bestResponse bool) [][]float64 {
+	level := traversal.level()
+	size := traversal.utilitySize()
+	if bestResponse {
+		size = len(traversal.Ranges[traversal.Traverser])
+	}
+	subResults := level.results(len(node.nextCards), size)
+	if !bestResponse {
+		node.splitAsymmetricCards(traversal, traverserReachProb, opponentReachProb, nil)
 	}
-	wg.Wait()
 
-	for index := range subResults {
-		for hand := range result {
-			result[hand] += subResults[index][hand]
+	for index := range node.nextNodes {
+		if node.sharesCanonical(index) || (!bestResponse && node.bucketCards(index) != nil) {
+			continue
 		}
+		level.wg.Add(1)
+		traversal.pool.run(subtreeTask{
+			chance: node,
+			traversal: traversal,
+			index: index,
+			travReach: traverserReachProb,
+			oppReach: opponentReachProb,
+			subResults: subResults,
+			bestResponse: bestResponse,
+			wg: &level.wg,
+		})
+	}
+	if !bestResponse {
+		node.dealBuckets(traversal, level, node.buckets, traverserReachProb, opponentReachProb, subResults)
 	}
+	level.wg.Wait()
 
-	if node.street == 1 {
-		for hand := range result {
-			result[hand] /= 45.0
+	//cards sharing a canonical subtree are handled by the same task since they update the same nodes
+	for group := range node.isomorphGroups {
+		level.wg.Add(1)
+		traversal.pool.run(subtreeTask{
+			chance: node,
+			traversal: traversal,
+			group: &node.isomorphGroups[group],
+			travReach: traverserReachProb,
+			oppReach: opponentReachProb,
+			subResults: subResults,
+			bestResponse: bestResponse,
+			wg: &level.wg,
+		})
+	}
+	level.wg.Wait()
+	return subResults
+}
+
+//dealBuckets hands the cards of every bucket to the worker pool as one task
+func (node *ChanceNode) dealBuckets(traversal *Traversal, level *scratchLevel, buckets [][]int, traverserReachProb,
+	opponentReachProb []float64, subResults [][]float64) {
+	for _, cards := range buckets {
+		if len(cards) == 0 {
+			continue
 		}
-	} else {
-		for hand := range result {
-			result[hand] /= 44.0
+		level.wg.Add(1)
+		traversal.pool.run(subtreeTask{
+			chance: node,
+			traversal: traversal,
+			cards: cards,
+			travReach: traverserReachProb,
+			oppReach: opponentReachProb,
+			subResults: subResults,
+			wg: &level.wg,
+		})
+	}
+}
+
+func (node *ChanceNode) dealCard(traversal *Traversal, index int, traverserReachProb, opponentReachProb []float64,
+	subResults [][]float64, bestResponse bool, wg *sync.WaitGroup) {
+	defer wg.Done()
+	node.traverseDealt(traversal, index, traverserReachProb, opponentReachProb, subResults, bestResponse, nil)
+}
+
+//dealBucket traverses the subtrees of the cards of a bucket in order, the values they share are updated once after
+//the last card
+func (node *ChanceNode) dealBucket(traversal *Traversal, cards []int, traverserReachProb, opponentReachProb []float64,
+	subResults [][]float64, wg *sync.WaitGroup) {
+	defer wg.Done()
+	bucket := newBucketUpdates()
+	for _, index := range cards {
+		node.traverseDealt(traversal, index, traverserReachProb, opponentReachProb, subResults, false, bucket)
+	}
+	bucket.apply(traversal.discounts())
+}
+
+//traverseDealt traverses the subtree of the card with the given index with a forked traversal and writes its
+//result to subResults. Isomorphic cards with a subtree of their own are traversed with the hands permuted. The
+//updates of the street are collected in bucket if it is not nil
+func (node *ChanceNode) traverseDealt(traversal *Traversal, index int, traverserReachProb, opponentReachProb []float64,
+	subResults [][]float64, bestResponse bool, bucket *bucketUpdates) {
+	worker := traversal.fork()
+	worker.bucket = bucket
+	level := worker.level()
+	if node.isomorphicCard(index) != nil {
+		nextTrav, nextOpp := node.permutedReach(traversal, level, index, traverserReachProb, opponentReachProb)
+		node.traversePermuted(traversal, worker, index, nextTrav, nextOpp, subResults, bestResponse)
+		traversal.release(worker)
+		return
+	}
+	card := node.nextCards[index]
+	nextTrav := removeCardReachInto(&level.dealtReach[0], traversal.Ranges[traversal.Traverser], traverserReachProb, card)
+	nextOpp := removeCardReachInto(&level.dealtReach[1], traversal.Ranges[traversal.Traverser^1], opponentReachProb, card)
+	worker.dealActive(level, cardTo52Int(card), node.traverserActive(traversal),
+		traversal.activeHands(traversal.Traverser^1), nextOpp)
+	copy(subResults[index], traverseCard(worker, node.nextNodes[index], nextTrav, nextOpp, bestResponse))
+	traversal.release(worker)
+}
+
+func (node *ChanceNode) dealIsomorphicCards(traversal *Traversal, group *isomorphGroup, traverserReachProb,
+	opponentReachProb []float64, subResults [][]float64, bestResponse bool, wg *sync.WaitGroup) {
+	defer wg.Done()
+	worker := traversal.fork()
+	worker.bucket = nil
+	level := worker.level()
+	travHands := traversal.Ranges[traversal.Traverser]
+	oppHands := traversal.Ranges[traversal.Traverser^1]
+
+	canonicalCard := node.nextCards[group.canonical]
+	canonicalTrav := removeCardReachInto(&level.canonicalReach[0], travHands, traverserReachProb, canonicalCard)
+	canonicalOpp := removeCardReachInto(&level.canonicalReach[1], oppHands, opponentReachProb, canonicalCard)
+	for _, i := range group.indexes {
+		if !node.sharesCanonical(i) {
+			continue
+		}
+		nextTrav, nextOpp := node.permutedReach(traversal, level, i, traverserReachProb, opponentReachProb)
+		if reachEqual(nextTrav, canonicalTrav) && reachEqual(nextOpp, canonicalOpp) {
+			traversal.unpermuteUtilityInto(subResults[i], subResults[group.canonical], node.isomorphs[i], bestResponse)
+		} else {
+			node.traversePermuted(traversal, worker, i, nextTrav, nextOpp, subResults, bestResponse)
 		}
 	}
-	return result
+	traversal.release(worker)
+}
+
+//permutedReach returns the reach probabilities of both players after the isomorphic card with the given index is
+//dealt, with the hands permuted to the hands playing in their place in the canonical subtree
+func (node *ChanceNode) permutedReach(traversal *Traversal, level *scratchLevel, index int, traverserReachProb,
+	opponentReachProb []float64) (nextTrav, nextOpp []float64) {
+	isomorphism := node.isomorphs[index]
+	travHands := traversal.Ranges[traversal.Traverser]
+	oppHands := traversal.Ranges[traversal.Traverser^1]
+	if traverserReachProb != nil {
+		removed := removeCardReachInto(&level.removedReach, travHands, traverserReachProb, node.nextCards[index])
+		level.dealtReach[0] = resize(level.dealtReach[0], len(removed))
+		nextTrav = permuteReachInto(level.dealtReach[0], removed, isomorphism.handPermutations[traversal.Traverser])
+	}
+	removed := removeCardReachInto(&level.removedReach, oppHands, opponentReachProb, node.nextCards[index])
+	level.dealtReach[1] = resize(level.dealtReach[1], len(removed))
+	nextOpp = permuteReachInto(level.dealtReach[1], removed, isomorphism.handPermutations[traversal.Traverser^1])
+	return nextTrav, nextOpp
+}
+
+//traversePermuted traverses the subtree of the isomorphic card with the given index, which is in the suits of the
+//canonical card, with the worker and writes the result for the hands of the card to subResults. Only best
+//responses traverse a subtree shared with the canonical card this way, since they do not update it
+func (node *ChanceNode) traversePermuted(traversal, worker *Traversal, index int, nextTrav, nextOpp []float64,
+	subResults [][]float64, bestResponse bool) {
+	isomorphism := node.isomorphs[index]
+	//the reach is permuted, so the opponent hands with reach are looked for in the whole range
+	worker.dealActive(worker.level(), cardTo52Int(node.nextCards[isomorphism.canonical]), node.traverserActive(traversal),
+		handIndexes[:len(nextOpp)], nextOpp)
+	result := traverseCard(worker, node.nextNodes[index], nextTrav, nextOpp, bestResponse)
+	traversal.unpermuteUtilityInto(subResults[index], result, isomorphism, bestResponse)
+}
+
+func traverseCard(worker *Traversal, next Node, traverserReachProb, opponentReachProb []float64, bestResponse bool) []float64 {
+	if bestResponse {
+		return next.BestResponse(worker, opponentReachProb)
+	}
+	return next.CFRTraversal(worker, traverserReachProb, opponentReachProb)
+}
+
+//removeCardReach returns a copy of reach with the hands containing card set to zero, or nil if reach is nil
+func removeCardReach(hands Range, reach []float64, card poker.Card) []float64 {
+	if reach == nil {
+		return nil
+	}
+	var next []float64
+	return removeCardReachInto(&next, hands, reach, card)
+}
+
+//removeCardReachInto is removeCardReach writing to the buffer, which is resized if needed
+func removeCardReachInto(buffer *[]float64, hands Range, reach []float64, card poker.Card) []float64 {
+	if reach == nil {
+		return nil
+	}
+	*buffer = resize(*buffer, len(reach))
+	next := *buffer
+	for hand := range next {
+		if hands[hand].Hand[0] == card || hands[hand].Hand[1] == card {
+			next[hand] = 0
+		} else {
+			next[hand] = reach[hand]
+		}
+	}
+	return next
+}
+
+//traverserActive returns the active traverser hands the subtrees of the dealt cards start from. The results of
+//canonical cards are reused with the hands permuted, so they need the utility of every hand whose permuted hand is
+//active. Hands are only active because of the board unless actions are pruned, and the board is symmetric
+//under the permutations, otherwise the whole range is used
+func (node *ChanceNode) traverserActive(traversal *Traversal) []int {
+	if traversal.pruning != nil && node.isomorphGroups != nil {
+		return handIndexes[:len(traversal.Ranges[traversal.Traverser])]
+	}
+	return traversal.activeHands(traversal.Traverser)
 }
 
+//isomorphicCard returns the isomorphism of the card with the given index, or nil if its subtree is in the suits
+//of the card itself
+func (node *ChanceNode) isomorphicCard(index int) *cardIsomorphism {
+	if node.isomorphs == nil {
+		return nil
+	}
+	return node.isomorphs[index]
+}
+
+//sharesCanonical returns if the card with the given index is isomorphic to a canonical card and shares its subtree
+func (node *ChanceNode) sharesCanonical(index int) bool {
+	isomorphism := node.isomorphicCard(index)
+	return isomorphism != nil && node.nextNodes[index] == node.nextNodes[isomorphism.canonical]
+}
+
+//splitAsymmetricCards gives every isomorphic card of cards, or of every card if cards is nil, a copy of the subtree
+//of its canonical card once its reach probabilities are not symmetric to those of the canonical card. A shared subtree would then be updated twice in an
+//iteration with the reach of two different spots, which happens when a locked strategy breaks the suit symmetry of
+//the ranges. The copy keeps the suits of the canonical card, so the card is still traversed with the hands permuted
+func (node *ChanceNode) splitAsymmetricCards(traversal *Traversal, traverserReachProb, opponentReachProb []float64,
+	cards []int) {
+	level := traversal.level()
+	travHands := traversal.Ranges[traversal.Traverser]
+	oppHands := traversal.Ranges[traversal.Traverser^1]
+	for _, group := range node.isomorphGroups {
+		var canonicalTrav, canonicalOpp []float64
+		for _, index := range group.indexes {
+			if !node.sharesCanonical(index) || (cards != nil && !sampledIndex(cards, index)) {
+				continue
+			}
+			if canonicalOpp == nil {
+				canonicalCard := node.nextCards[group.canonical]
+				canonicalTrav = removeCardReachInto(&level.canonicalReach[0], travHands, traverserReachProb, canonicalCard)
+				canonicalOpp = removeCardReachInto(&level.canonicalReach[1], oppHands, opponentReachProb, canonicalCard)
+			}
+			nextTrav, nextOpp := node.permutedReach(traversal, level, index, traverserReachProb, opponentReachProb)
+			if !reachEqual(nextTrav, canonicalTrav) || !reachEqual(nextOpp, canonicalOpp) {
+				node.nextNodes[index] = cloneSubtree(node.nextNodes[group.canonical])
+			}
+		}
+	}
+}
+
+//splitCards returns the isomorphic cards with a subtree of their own
+func (node *ChanceNode) splitCards() []int {
+	var split []int
+	for index := range node.isomorphs {
+		if node.isomorphs[index] != nil && !node.sharesCanonical(index) {
+			split = append(split, index)
+		}
+	}
+	return split
+}
+
+//splitCardsAt gives the isomorphic cards at the given indexes a copy of the subtree of their canonical card
+func (node *ChanceNode) splitCardsAt(indexes []int) error {
+	for _, index := range indexes {
+		if index < 0 || index >= len(node.nextNodes) || node.isomorphicCard(index) == nil {
+			return fmt.Errorf("card %v of the chance node is not isomorphic to another card", index)
+		}
+		if node.sharesCanonical(index) {
+			node.nextNodes[index] = cloneSubtree(node.nextNodes[node.isomorphs[index].canonical])
+		}
+	}
+	return nil
+}
 
 //GetTraverserHandWeightingForCard - for each of the traverser's hands, calculate the
 //weight of each river card given the opponents range. Basically, we need to take into account the chance that
@@ -180,39 +473,36 @@ func (node *ChanceNode) BestResponse(traversal *Traversal, opponentReachProb []f
 //NOTE: Pretty sure this is not necessary results seem to be the same either way, need to think on that more
 func (node *ChanceNode) GetTraverserHandWeightingForCard(traversal *Traversal, opponentReachProb []float64) []float64  {
 	travHands := traversal.Ranges[traversal.Traverser]
-	oppHands := traversal.Ranges[traversal.Traverser ^ 1]
 	weight := make([]float64, len(node.nextCards) * len(travHands))
 
 	cardRemoval := make([]float64, 52)
 	probabilitySum := 0.0
 
+	oppCards := traversal.cardIndexes[traversal.Traverser ^ 1]
 	for handIndex := range opponentReachProb {
-		cardRemoval[cardTo52Int(oppHands[handIndex].Hand[0])] += opponentReachProb[handIndex]
-		cardRemoval[cardTo52Int(oppHands[handIndex].Hand[1])] += opponentReachProb[handIndex]
+		cardRemoval[oppCards[handIndex][0]] += opponentReachProb[handIndex]
+		cardRemoval[oppCards[handIndex][1]] += opponentReachProb[handIndex]
 
 		probabilitySum += opponentReachProb[handIndex]
 	}
 
 
+	nextCards := make([]int, len(node.nextCards))
+	for card := range node.nextCards {
+		nextCards[card] = cardTo52Int(node.nextCards[card])
+	}
+	travCards := traversal.cardIndexes[traversal.Traverser]
 	for handIndex := range travHands {
 		weightSum := 0.0
+		hand := travCards[handIndex]
+		sameHandIndex := traversal.IndexCaches[traversal.Traverser ^ 1][traversal.comboIndexes[traversal.Traverser][handIndex]]
 		for card := range node.nextCards {
-			hand := travHands[handIndex].Hand
-			if hand[0] == node.nextCards[card] || hand[1] == node.nextCards[card] {
+			if hand[0] == nextCards[card] || hand[1] == nextCards[card] {
 				continue
 			}
-			var curWeight float64
-			sameHandIndex, ok := traversal.IndexCaches[traversal.Traverser ^ 1][hand]
-			if ok {
-				curWeight = probabilitySum -
-					cardRemoval[cardTo52Int(node.nextCards[card])] -
-					cardRemoval[cardTo52Int(hand[0])] -
-					cardRemoval[cardTo52Int(hand[1])] + opponentReachProb[sameHandIndex]
-			} else {
-				curWeight = probabilitySum -
-					cardRemoval[cardTo52Int(node.nextCards[card])] -
-					cardRemoval[cardTo52Int(hand[0])] -
-					cardRemoval[cardTo52Int(hand[1])]
+			curWeight := probabilitySum - cardRemoval[nextCards[card]] - cardRemoval[hand[0]] - cardRemoval[hand[1]]
+			if sameHandIndex >= 0 {
+				curWeight += opponentReachProb[sameHandIndex]
 			}
 			weight[handIndex + card * len(travHands)] = curWeight
 			weightSum += curWeight
@@ -0,0 +1,152 @@
+package solv
+
+//chanceSampling makes CFR traversals deal only samples of the cards of every ChanceNode and of the runouts of
+//every AllInShowdownNode that has no equity table, the utility of the samples is scaled up to estimate the
+//utility over all of them. This is public chance sampling, every hand is still updated at once at every visited
+//node. The samples only depend on the seed, the iteration, the traverser and the board, so a solve picks the same
+//cards whatever the worker pool, and every node dealing from the same board deals the same cards
+type chanceSampling struct {
+	samples int
+	seed    uint64
+}
+
+//SetChanceSampling sets the number of cards dealt at each chance node, and of runouts evaluated at all in nodes
+//without an equity table, during CFR traversals. Iterations are much cheaper since most of the tree is skipped,
+//but each one only estimates the utilities, so more of them are needed. Best responses always deal every card.
+//Nodes below a card are only updated and discounted in the iterations that deal it, so they are discounted less than
+//in an exact walk. Solves with the same seed sample the same cards, a non positive number of samples deals every
+//card again
+func (traversal *Traversal) SetChanceSampling(samples int, seed int64) {
+	if samples <= 0 {
+		traversal.sampling = nil
+		return
+	}
+	traversal.sampling = &chanceSampling{samples: samples, seed: uint64(seed)}
+}
+
+//mix is the splitmix64 finalizer, it spreads every bit of x over the result
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+//sample writes the indexes of up to the sampled number of distinct items out of n to buffer and returns them, the
+//items are picked by a partial shuffle seeded by the sampling seed, the iteration, the traverser and key
+func (traversal *Traversal) sample(buffer *[]int, n int, key uint64) []int {
+	indexes := (*buffer)[:0]
+	for index := 0; index < n; index++ {
+		indexes = append(indexes, index)
+	}
+	*buffer = indexes
+	samples := traversal.sampling.samples
+	if samples > n {
+		samples = n
+	}
+	state := mix(traversal.sampling.seed ^ mix(uint64(traversal.Iteration)^mix(uint64(traversal.Traverser)^mix(key))))
+	for i := 0; i < samples; i++ {
+		state = mix(state)
+		j := i + int(state%uint64(n-i))
+		indexes[i], indexes[j] = indexes[j], indexes[i]
+	}
+	//the samples are visited in the order of the items like when every item is dealt
+	sampled := indexes[:samples]
+	for i := 1; i < len(sampled); i++ {
+		for j := i; j > 0 && sampled[j] < sampled[j-1]; j-- {
+			sampled[j], sampled[j-1] = sampled[j-1], sampled[j]
+		}
+	}
+	return sampled
+}
+
+//dealSampledCards traverses the subtrees of a sample of the dealt cards and returns the indexes of the cards with
+//their results in the scratch buffers of the traversal. Sampled isomorphic cards with reach probabilities that are
+//not symmetric to their canonical card get a subtree of their own like in dealCards. The other ones reuse the
+//result of their canonical card, which is traversed even when it was not sampled itself, so a shared subtree is
+//updated once however many of its cards are sampled. The sampled cards of a bucket are traversed by one task like
+//in dealCards
+func (node *ChanceNode) dealSampledCards(traversal *Traversal, traverserReachProb, opponentReachProb []float64) ([]int,
+	[][]float64) {
+	level := traversal.level()
+	subResults := level.results(len(node.nextCards), traversal.utilitySize())
+	//the cards left in the deck tell the boards apart
+	sampled := traversal.sample(&level.sampled, len(node.nextCards), boardMask(node.nextCards))
+	node.splitAsymmetricCards(traversal, traverserReachProb, opponentReachProb, sampled)
+
+	for _, group := range node.isomorphGroups {
+		shared := false
+		for _, index := range group.indexes {
+			shared = shared || (node.sharesCanonical(index) && sampledIndex(sampled, index))
+		}
+		if shared && !sampledIndex(sampled, group.canonical) {
+			node.dealSampledCard(traversal, level, group.canonical, traverserReachProb, opponentReachProb, subResults)
+		}
+	}
+	for _, index := range sampled {
+		if node.sharesCanonical(index) || node.bucketCards(index) != nil {
+			continue
+		}
+		node.dealSampledCard(traversal, level, index, traverserReachProb, opponentReachProb, subResults)
+	}
+	buckets := level.bucketSamples(len(node.buckets))
+	for bucket := range buckets {
+		buckets[bucket] = buckets[bucket][:0]
+		for _, index := range node.buckets[bucket] {
+			if sampledIndex(sampled, index) {
+				buckets[bucket] = append(buckets[bucket], index)
+			}
+		}
+	}
+	node.dealBuckets(traversal, level, buckets, traverserReachProb, opponentReachProb, subResults)
+	level.wg.Wait()
+
+	for _, index := range sampled {
+		if node.sharesCanonical(index) {
+			canonical := node.isomorphs[index].canonical
+			traversal.unpermuteUtilityInto(subResults[index], subResults[canonical], node.isomorphs[index], false)
+		}
+	}
+	return sampled, subResults
+}
+
+//dealSampledCard hands the card with the given index to the worker pool
+func (node *ChanceNode) dealSampledCard(traversal *Traversal, level *scratchLevel, index int, traverserReachProb,
+	opponentReachProb []float64, subResults [][]float64) {
+	level.wg.Add(1)
+	traversal.pool.run(subtreeTask{
+		chance: node,
+		traversal: traversal,
+		index: index,
+		travReach: traverserReachProb,
+		oppReach: opponentReachProb,
+		subResults: subResults,
+		wg: &level.wg,
+	})
+}
+
+func sampledIndex(sampled []int, index int) bool {
+	for _, sample := range sampled {
+		if sample == index {
+			return true
+		}
+	}
+	return false
+}
+
+//sampledCFRTraversal estimates the utility of the chance node from a sample of the dealt cards
+func (node *ChanceNode) sampledCFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
+	sampled, subResults := node.dealSampledCards(traversal, traverserReachProb, opponentReachProb)
+	result := traversal.level().utility(traversal.utilitySize())
+	for _, index := range sampled {
+		for hand := range result {
+			result[hand] += subResults[index][hand]
+		}
+	}
+	//every sampled card stands for len(nextCards) / len(sampled) cards
+	scale := float64(len(node.nextCards)) / float64(len(sampled)) / node.dealtCards()
+	for hand := range result {
+		result[hand] *= scale
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newFlopTestTree(params *ConstructionParams) (*GameNode, *Traversal) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s")}
+	oop := RemoveConflicts(HandsStringToHandRange("TT+, AQs+, KQs, AK"), board)
+	ip := RemoveConflicts(HandsStringToHandRange("99+, ATs+, KTs+, QJs, AQ+"), board)
+	return mustConstructTree(100, 150, params, ip, oop, board), NewTraversal(oop, ip)
+}
+
+func TestSampleIsSeeded(t *testing.T) {
+	traversal := NewTraversal(nil, nil)
+	traversal.SetChanceSampling(3, 7)
+	var buffer []int
+	sampled := append([]int(nil), traversal.sample(&buffer, 48, 42)...)
+	assert.Len(t, sampled, 3)
+	assert.NotEqual(t, sampled[0], sampled[1])
+	assert.NotEqual(t, sampled[0], sampled[2])
+	assert.NotEqual(t, sampled[1], sampled[2])
+	assert.Equal(t, sampled, traversal.sample(&buffer, 48, 42))
+
+	traversal.Iteration++
+	assert.NotEqual(t, sampled, traversal.sample(&buffer, 48, 42))
+	traversal.SetChanceSampling(3, 8)
+	traversal.Iteration--
+	assert.NotEqual(t, sampled, traversal.sample(&buffer, 48, 42))
+	assert.Len(t, traversal.sample(&buffer, 2, 42), 2)
+}
+
+func TestSamplingEveryCardMatchesFullTraversal(t *testing.T) {
+	params := NewConstructionParams(0.75, 1.2)
+	//all in nodes evaluate their runouts so the runouts are sampled as well
+	params.SetEquityTables(false)
+	root, traversal := newFlopTestTree(params)
+	runIterations(traversal, 3, root)
+
+	sampledRoot, sampledTrav := newFlopTestTree(params)
+	sampledTrav.SetChanceSampling(2000, 1)
+	runIterations(sampledTrav, 3, sampledRoot)
+
+	//only the order of the sums over cards changes
+	assertSamePlayerValues(t, root, sampledRoot, 0)
+	assertSamePlayerValues(t, root, sampledRoot, 1)
+}
+
+func TestChanceSamplingIsReproducible(t *testing.T) {
+	roots := make([]*GameNode, 0, 3)
+	for _, workers := range []int{1, 4} {
+		root, traversal := newFlopTestTree(NewConstructionParams(0.75, 1.2))
+		pool := NewWorkerPool(workers)
+		traversal.SetWorkerPool(pool)
+		traversal.SetChanceSampling(2, 11)
+		runIterations(traversal, 20, root)
+		pool.Close()
+		roots = append(roots, root)
+	}
+	assertSameValues(t, roots[0], roots[1])
+
+	root, traversal := newFlopTestTree(NewConstructionParams(0.75, 1.2))
+	traversal.SetChanceSampling(2, 12)
+	runIterations(traversal, 20, root)
+	assert.NotEqual(t, roots[0].Regrets(0), root.Regrets(0))
+}
+
+func TestChanceSamplingConverges(t *testing.T) {
+	root, traversal := newFlopTestTree(NewConstructionParams(0.75, 1.2))
+	_, _, start := Exploitability(traversal, root)
+	traversal.SetChanceSampling(12, 3)
+	runIterations(traversal, 200, root)
+	_, _, exploitability := Exploitability(traversal, root)
+	assert.Less(t, exploitability, start/5)
+}
+
+func TestSamplingEveryCardOfIsomorphicTreeWithAsymmetricReach(t *testing.T) {
+	isomorphic, isoTrav := newIsomorphismTestTree(true)
+	full, fullTrav := newIsomorphismTestTree(false)
+	//the lock makes cards asymmetric to their canonical card, so they need their own subtree when they are sampled
+	locked := NewHand("9h", "8d")
+	for _, root := range []*GameNode{isomorphic, full} {
+		strategy := make([][]float64, len(isoTrav.Ranges[0]))
+		for hand := range strategy {
+			strategy[hand] = []float64{1, 1}
+		}
+		strategy[isoTrav.HandIndex(0, locked)] = []float64{1, 0}
+		assert.Nil(t, root.LockStrategy(strategy))
+	}
+	isoTrav.SetChanceSampling(2000, 5)
+	fullTrav.SetChanceSampling(2000, 5)
+	runIterations(isoTrav, 20, isomorphic)
+	runIterations(fullTrav, 20, full)
+	isoOOP, isoIP, _ := Exploitability(isoTrav, isomorphic)
+	fullOOP, fullIP, _ := Exploitability(fullTrav, full)
+	assert.InDelta(t, fullOOP, isoOOP, 0.0001)
+	assert.InDelta(t, fullIP, isoIP, 0.0001)
+	assert.Greater(t, countSplitCards(isomorphic), 0)
+}
@@ -0,0 +1,176 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"runtime"
+	"sync"
+)
+
+//EquityTable holds the all in outcome of every oop hand against every ip hand on a flop or turn board. For each
+//pair it stores the number of runouts the oop hand wins minus the number it loses, pairs that overlap each other
+//or the board are zero. Every pair that does not overlap sees the same number of runouts, so dividing by that
+//number gives the average all in result of the pair. Turn boards fit in an int8 per pair, flop boards in an int16
+type EquityTable struct {
+	numOOP  int
+	numIP   int
+	runouts float64
+	flop    []int16
+	turn    []int8
+}
+
+//NewEquityTable evaluates every runout of board for the hands of both ranges with the default evaluator
+func NewEquityTable(board []poker.Card, oopRange, ipRange Range) *EquityTable {
+	return newEquityTable(board, oopRange, ipRange, NewRankCache(nil), Holdem)
+}
+
+//newEquityTable evaluates every runout of board dealt from the deck of variant with the ranks of the cache
+func newEquityTable(board []poker.Card, oopRange, ipRange Range, ranks *RankCache, variant Variant) *EquityTable {
+	runouts := constructPossibleRunouts(board, variant)
+	table := EquityTable{
+		numOOP:  len(oopRange),
+		numIP:   len(ipRange),
+		runouts: float64(variant.pairRunouts(len(board))),
+	}
+	if len(board) == 3 {
+		table.flop = make([]int16, table.numOOP*table.numIP)
+	} else {
+		table.turn = make([]int8, table.numOOP*table.numIP)
+	}
+
+	oopRanks := rankRunouts(runouts, oopRange, ranks)
+	ipRanks := rankRunouts(runouts, ipRange, ranks)
+
+	//rows are split between the workers so every entry is only written by one goroutine
+	workers := runtime.GOMAXPROCS(0)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for worker := 0; worker < workers; worker++ {
+		go func(worker int) {
+			defer wg.Done()
+			outcomes := make([]int, table.numIP)
+			for oop := worker; oop < table.numOOP; oop += workers {
+				for ip := range outcomes {
+					outcomes[ip] = 0
+				}
+				for runout := range runouts {
+					oopRank := oopRanks[runout][oop]
+					if oopRank == 0 {
+						continue
+					}
+					for ip, ipRank := range ipRanks[runout] {
+						if ipRank == 0 || CheckHandOverlap(oopRange[oop].Hand, ipRange[ip].Hand) {
+							continue
+						}
+						if oopRank < ipRank {
+							outcomes[ip]++
+						} else if oopRank > ipRank {
+							outcomes[ip]--
+						}
+					}
+				}
+				for ip := range outcomes {
+					if table.flop != nil {
+						table.flop[oop*table.numIP+ip] = int16(outcomes[ip])
+					} else {
+						table.turn[oop*table.numIP+ip] = int8(outcomes[ip])
+					}
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	return &table
+}
+
+//rankRunouts returns the rank of every hand of rng on every runout, or 0 if the hand overlaps the runout. The
+//runouts are split between the workers like the rows of the table
+func rankRunouts(runouts [][]poker.Card, rng Range, ranks *RankCache) [][]int32 {
+	rankings := make([][]int32, len(runouts))
+	workers := runtime.GOMAXPROCS(0)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for worker := 0; worker < workers; worker++ {
+		go func(worker int) {
+			defer wg.Done()
+			for index := worker; index < len(runouts); index += workers {
+				boardRanks := ranks.Ranks(runouts[index])
+				rankings[index] = make([]int32, len(rng))
+				for hand := range rng {
+					if CheckHandBoardOverlap(rng[hand].Hand, runouts[index]) {
+						continue
+					}
+					rankings[index][hand] = int32(boardRanks[rng[hand].Hand.ComboIndex()])
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	return rankings
+}
+
+//outcome returns the average all in result of the oop hand against the ip hand, between -1 and 1
+func (table *EquityTable) outcome(oop, ip int) float64 {
+	if table.flop != nil {
+		return float64(table.flop[oop*table.numIP+ip]) / table.runouts
+	}
+	return float64(table.turn[oop*table.numIP+ip]) / table.runouts
+}
+
+//Utility returns the all in utility of every hand of the traverser against the opponent reach probabilities
+//when each player wins winUtility from the pot
+func (table *EquityTable) Utility(traverser int, opponentReachProb []float64, winUtility float64) []float64 {
+	utility := make([]float64, table.numOOP)
+	if traverser == 1 {
+		utility = make([]float64, table.numIP)
+	}
+	table.utilityInto(utility, traverser, opponentReachProb, winUtility)
+	return utility
+}
+
+//utilityInto writes the utility of every traverser hand to utility, which must be zeroed
+func (table *EquityTable) utilityInto(utility []float64, traverser int, opponentReachProb []float64, winUtility float64) {
+	if traverser == 0 {
+		for oop := range utility {
+			sum := 0.0
+			row := oop * table.numIP
+			if table.flop != nil {
+				for ip, outcome := range table.flop[row : row+table.numIP] {
+					sum += float64(outcome) * opponentReachProb[ip]
+				}
+			} else {
+				for ip, outcome := range table.turn[row : row+table.numIP] {
+					sum += float64(outcome) * opponentReachProb[ip]
+				}
+			}
+			utility[oop] = sum * winUtility / table.runouts
+		}
+		return
+	}
+	for oop := 0; oop < table.numOOP; oop++ {
+		reach := opponentReachProb[oop]
+		if reach == 0 {
+			continue
+		}
+		row := oop * table.numIP
+		if table.flop != nil {
+			for ip, outcome := range table.flop[row : row+table.numIP] {
+				utility[ip] -= float64(outcome) * reach
+			}
+		} else {
+			for ip, outcome := range table.turn[row : row+table.numIP] {
+				utility[ip] -= float64(outcome) * reach
+			}
+		}
+	}
+	for ip := range utility {
+		utility[ip] *= winUtility / table.runouts
+	}
+}
+
+//equityTableCheaperThanRunouts estimates if a matrix vector product over the table is faster than evaluating a showdown
+//on every runout, which is the case for flop boards but not for turn boards with large ranges
+func equityTableCheaperThanRunouts(board []poker.Card, oopRange, ipRange Range, variant Variant) bool {
+	runouts := variant.pairRunouts(len(board))
+	//a runout costs two sweeps over both ranges plus copying the reach probabilities
+	return len(oopRange)*len(ipRange) <= 4*runouts*(len(oopRange)+len(ipRange))
+}
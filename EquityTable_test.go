@@ -0,0 +1,117 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+var equityFlop = []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s")}
+var equityTurn = []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c")}
+
+//newAllInTestNodes returns an AllInShowdownNode using the equity table of board and one evaluating every runout
+func newAllInTestNodes(board []poker.Card, oopHands, ipHands string) (*AllInShowdownNode, *AllInShowdownNode, *Traversal) {
+	oop := RemoveConflicts(HandsStringToHandRange(oopHands), board)
+	ip := RemoveConflicts(HandsStringToHandRange(ipHands), board)
+	cache := NewRiverEvaluationCache(oop, ip)
+	street := 1
+	if len(board) == 4 {
+		street = 2
+	}
+	table := NewAllInShowdownNode(100, street, cache)
+	table.table = cache.EquityTable(board)
+	runouts := NewAllInShowdownNode(100, street, cache)
+	for _, runout := range constructPossibleRunouts(board, cache.variant) {
+		showdown := NewShowdownNode(runouts.potSize, 0, runout, cache)
+		showdown.cacheIndex = cache.InsertBoard(runout)
+		runouts.AddNextNode(showdown)
+	}
+	return table, runouts, NewTraversal(oop, ip)
+}
+
+func randomReach(n int, random *rand.Rand) []float64 {
+	reach := make([]float64, n)
+	for hand := range reach {
+		reach[hand] = random.Float64()
+	}
+	return reach
+}
+
+func TestEquityTableMatchesRunouts(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, board := range [][]poker.Card{equityFlop, equityTurn} {
+		table, runouts, traversal := newAllInTestNodes(board, "AA, KQ, 99, 87s, 65s", "KK, AK, T9s, 44, 32s")
+		for traverser := range traversal.Ranges {
+			traversal.Traverser = traverser
+			traverserReach := randomReach(len(traversal.Ranges[traverser]), random)
+			opponentReach := randomReach(len(traversal.Ranges[traverser^1]), random)
+			//the utility returned by CFRTraversal is a scratch buffer of the traversal
+			expected := append([]float64(nil), runouts.CFRTraversal(traversal, traverserReach, opponentReach)...)
+			assert.InDeltaSlice(t, expected, table.CFRTraversal(traversal, traverserReach, opponentReach), 1e-9)
+			assert.InDeltaSlice(t, runouts.BestResponse(traversal, opponentReach),
+				table.BestResponse(traversal, opponentReach), 1e-9)
+		}
+	}
+}
+
+func TestEquityTableOutcome(t *testing.T) {
+	_, _, traversal := newAllInTestNodes(equityTurn, "AA", "KK")
+	table := NewEquityTable(equityTurn, traversal.Ranges[0], traversal.Ranges[1])
+	//aces only win when one of the two remaining aces comes
+	assert.InDelta(t, (2.0-42.0)/44.0, table.outcome(0, 0), 1e-12)
+	assert.Equal(t, 44.0, table.runouts)
+	assert.Equal(t, 990.0, NewEquityTable(equityFlop, traversal.Ranges[0], traversal.Ranges[1]).runouts)
+}
+
+func TestEquityTableUsedOnFlop(t *testing.T) {
+	oop := RemoveConflicts(HandsStringToHandRange("AA, KQ, 99"), equityFlop)
+	ip := RemoveConflicts(HandsStringToHandRange("KK, AK, T9s"), equityFlop)
+	for _, enabled := range []bool{true, false} {
+		params := NewConstructionParams(1.0, 1.2)
+		params.SetEquityTables(enabled)
+		board := make([]poker.Card, len(equityFlop))
+		copy(board, equityFlop)
+		root := mustConstructTree(100, 50, params, ip, oop, board)
+		var allIn *AllInShowdownNode
+		for _, next := range root.GetNext(1).(*GameNode).nextNodes {
+			if node, ok := next.(*AllInShowdownNode); ok {
+				allIn = node
+			}
+		}
+		assert.NotNil(t, allIn)
+		assert.Equal(t, enabled, allIn.table != nil)
+		assert.Equal(t, enabled, len(allIn.nextNodes) == 0)
+	}
+}
+
+func benchmarkAllInShowdownNode(b *testing.B, board []poker.Card, useTable bool) {
+	table, runouts, traversal := newAllInTestNodes(board, "22+, A2s+, K9s+, QTs+, JTs, ATo+, KJo+",
+		"22+, A2s+, K2s+, Q8s+, J8s+, T8s+, 97s+, 87s, A8o+, KTo+, QTo+")
+	node := runouts
+	if useTable {
+		node = table
+	}
+	traverserReach := convertRangeToFloatSlice(traversal.Ranges[0])
+	opponentReach := convertRangeToFloatSlice(traversal.Ranges[1])
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		node.CFRTraversal(traversal, traverserReach, opponentReach)
+	}
+}
+
+func BenchmarkAllInShowdownNodeFlopRunouts(b *testing.B) {
+	benchmarkAllInShowdownNode(b, equityFlop, false)
+}
+
+func BenchmarkAllInShowdownNodeFlopEquityTable(b *testing.B) {
+	benchmarkAllInShowdownNode(b, equityFlop, true)
+}
+
+func BenchmarkAllInShowdownNodeTurnRunouts(b *testing.B) {
+	benchmarkAllInShowdownNode(b, equityTurn, false)
+}
+
+func BenchmarkAllInShowdownNodeTurnEquityTable(b *testing.B) {
+	benchmarkAllInShowdownNode(b, equityTurn, true)
+}
@@ -0,0 +1,307 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"math/bits"
+	"sort"
+	"sync"
+)
+
+//Evaluator ranks hands of five to seven cards. A lower rank is a better hand and equal ranks split the pot.
+//Evaluators ranking hands by the rules of another variant than hold'em tell it with a Variant method like the
+//TableEvaluator, other evaluators are taken to rank hands like hold'em
+type Evaluator interface {
+	Evaluate(cards []poker.Card) int32
+}
+
+//evaluatorVariant returns the variant whose rules evaluator ranks hands by
+func evaluatorVariant(evaluator Evaluator) Variant {
+	if ranked, ok := evaluator.(interface{ Variant() Variant }); ok {
+		return ranked.Variant()
+	}
+	return Holdem
+}
+
+//PokerEvaluator ranks hands with poker.Evaluate, which tries every five card subset of a hand
+type PokerEvaluator struct{}
+
+func (PokerEvaluator) Evaluate(cards []poker.Card) int32 {
+	return poker.Evaluate(cards)
+}
+
+//hand categories of the table evaluator, a better category always beats a worse one
+const (
+	highCard = iota
+	onePair
+	twoPair
+	threeOfAKind
+	straight
+	flush
+	fullHouse
+	fourOfAKind
+	straightFlush
+)
+
+const maxEvaluatedCards = 7
+
+//handRules are the rules a variant ranks five card hands by
+type handRules struct {
+	//lowStraight is the bit set of the ranks of the straight playing the ace as its lowest card and lowStraightTop
+	//the highest rank of that straight
+	lowStraight    int
+	lowStraightTop int
+	//flushBeatsFullHouse swaps the order of flushes and full houses
+	flushBeatsFullHouse bool
+}
+
+//holdemRules rank hands like poker.Evaluate with the wheel as the lowest straight
+var holdemRules = handRules{lowStraight: 0x100f, lowStraightTop: 3}
+
+//shortDeckRules rank hands like short deck hold'em, where A6789 is the lowest straight and a flush beats a full
+//house since it is harder to make without the low cards
+var shortDeckRules = handRules{lowStraight: 0x10f0, lowStraightTop: 7, flushBeatsFullHouse: true}
+
+//TableEvaluator ranks hands with two lookup tables, from 1 for a royal flush to the worst high card. The hold'em
+//tables give the same ranks as poker.Evaluate, 7462 for seven high. Hands with five cards of a suit are looked up
+//by the bit set of the ranks of that suit, since seven cards with a flush can not hold quads or a full house
+//that beats it. All other hands are looked up by the number of cards of every rank
+type TableEvaluator struct {
+	variant  Variant
+	flushes  [1 << 13]int16
+	unsuited []int16
+	//offsets[rank][remaining][count] is added to the unsuited index when rank is held count times with remaining
+	//cards left to place on rank and the higher ranks
+	offsets [13][maxEvaluatedCards + 1][5]int32
+}
+
+var defaultEvaluator *TableEvaluator
+var defaultEvaluatorOnce sync.Once
+var defaultShortDeckEvaluator *TableEvaluator
+var defaultShortDeckEvaluatorOnce sync.Once
+
+//DefaultEvaluator returns the shared TableEvaluator, the tables are built the first time it is used
+func DefaultEvaluator() *TableEvaluator {
+	defaultEvaluatorOnce.Do(func() {
+		defaultEvaluator = NewTableEvaluator()
+	})
+	return defaultEvaluator
+}
+
+//DefaultShortDeckEvaluator returns the shared short deck TableEvaluator, the tables are built the first time it is
+//used
+func DefaultShortDeckEvaluator() *TableEvaluator {
+	defaultShortDeckEvaluatorOnce.Do(func() {
+		defaultShortDeckEvaluator = NewShortDeckEvaluator()
+	})
+	return defaultShortDeckEvaluator
+}
+
+//NewTableEvaluator builds the lookup tables of every rank count and flush of up to seven cards
+func NewTableEvaluator() *TableEvaluator {
+	return newTableEvaluator(Holdem)
+}
+
+//NewShortDeckEvaluator builds the lookup tables ranking hands by the short deck rules. Hands holding the twos to
+//fives are ranked as well, so every hand has a rank, but they are never dealt in short deck
+func NewShortDeckEvaluator() *TableEvaluator {
+	return newTableEvaluator(ShortDeck)
+}
+
+func newTableEvaluator(variant Variant) *TableEvaluator {
+	rules := variant.rules()
+	evaluator := &TableEvaluator{variant: variant}
+	//ways[rank][cards] is the number of ways to hold at most cards cards of rank and the ranks above it
+	var ways [14][maxEvaluatedCards + 1]int32
+	for cards := range ways[13] {
+		ways[13][cards] = 1
+	}
+	for rank := 12; rank >= 0; rank-- {
+		for cards := 0; cards <= maxEvaluatedCards; cards++ {
+			for count := 0; count <= 4 && count <= cards; count++ {
+				evaluator.offsets[rank][cards][count] = ways[rank][cards]
+				ways[rank][cards] += ways[rank+1][cards-count]
+			}
+		}
+	}
+	evaluator.unsuited = make([]int16, ways[0][maxEvaluatedCards])
+
+	ranks := fiveCardRanks(rules)
+	var counts [13]int
+	evaluator.fillUnsuited(&counts, 0, 0, rules, ranks)
+	for mask := range evaluator.flushes {
+		if bits.OnesCount(uint(mask)) >= 5 {
+			evaluator.flushes[mask] = int16(ranks[flushScore(mask, rules)])
+		}
+	}
+	return evaluator
+}
+
+//Variant returns the variant whose rules the evaluator ranks hands by
+func (evaluator *TableEvaluator) Variant() Variant {
+	return evaluator.variant
+}
+
+//fillUnsuited sets the rank of every count of the ranks from rank on, with cards cards on the lower ranks
+func (evaluator *TableEvaluator) fillUnsuited(counts *[13]int, rank, cards int, rules handRules, ranks map[int]int) {
+	if rank == 13 {
+		if cards >= 5 {
+			evaluator.unsuited[evaluator.unsuitedIndex(counts)] = int16(ranks[unsuitedScore(counts, rules)])
+		}
+		return
+	}
+	for count := 0; count <= 4 && cards+count <= maxEvaluatedCards; count++ {
+		counts[rank] = count
+		evaluator.fillUnsuited(counts, rank+1, cards+count, rules, ranks)
+	}
+	counts[rank] = 0
+}
+
+func (evaluator *TableEvaluator) unsuitedIndex(counts *[13]int) int32 {
+	index := int32(0)
+	remaining := maxEvaluatedCards
+	for rank, count := range counts {
+		index += evaluator.offsets[rank][remaining][count]
+		remaining -= count
+	}
+	return index
+}
+
+//Evaluate returns the rank of a hand of five to seven cards
+func (evaluator *TableEvaluator) Evaluate(cards []poker.Card) int32 {
+	var counts [13]int
+	var suits [4]int
+	for _, card := range cards {
+		rank := card.Rank()
+		counts[rank]++
+		suits[bits.TrailingZeros32(uint32(card.Suit()))] |= 1 << uint(rank)
+	}
+	for _, mask := range suits {
+		if bits.OnesCount(uint(mask)) >= 5 {
+			return int32(evaluator.flushes[mask])
+		}
+	}
+	return int32(evaluator.unsuited[evaluator.unsuitedIndex(&counts)])
+}
+
+//fiveCardRanks maps the score of every distinct five card hand under rules to its rank, the best score getting
+//rank 1
+func fiveCardRanks(rules handRules) map[int]int {
+	unique := make(map[int]bool)
+	var counts [13]int
+	var collect func(rank, cards int)
+	collect = func(rank, cards int) {
+		if rank == 13 {
+			if cards == 5 {
+				unique[unsuitedScore(&counts, rules)] = true
+			}
+			return
+		}
+		for count := 0; count <= 4 && cards+count <= 5; count++ {
+			counts[rank] = count
+			collect(rank+1, cards+count)
+		}
+		counts[rank] = 0
+	}
+	collect(0, 0)
+	for mask := 0; mask < 1<<13; mask++ {
+		if bits.OnesCount(uint(mask)) == 5 {
+			unique[flushScore(mask, rules)] = true
+		}
+	}
+
+	scores := make([]int, 0, len(unique))
+	for score := range unique {
+		scores = append(scores, score)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(scores)))
+	ranks := make(map[int]int, len(scores))
+	for index, score := range scores {
+		ranks[score] = index + 1
+	}
+	return ranks
+}
+
+//handScore orders hands by category under rules and then by the ranks deciding between hands of the category
+func handScore(rules handRules, category int, kickers ...int) int {
+	if rules.flushBeatsFullHouse && category == flush {
+		category = fullHouse
+	} else if rules.flushBeatsFullHouse && category == fullHouse {
+		category = flush
+	}
+	score := category
+	for i := 0; i < 5; i++ {
+		score *= 13
+		if i < len(kickers) {
+			score += kickers[i]
+		}
+	}
+	return score
+}
+
+//straightTop returns the highest rank of the best straight under rules in the bit set of ranks, or -1 if there is
+//none
+func straightTop(mask int, rules handRules) int {
+	for top := 12; top >= 4; top-- {
+		if mask>>uint(top-4)&0x1f == 0x1f {
+			return top
+		}
+	}
+	if mask&rules.lowStraight == rules.lowStraight {
+		return rules.lowStraightTop
+	}
+	return -1
+}
+
+//highestRanks returns the n highest ranks of the bit set of ranks
+func highestRanks(mask, n int) []int {
+	highest := make([]int, 0, n)
+	for rank := 12; rank >= 0 && len(highest) < n; rank-- {
+		if mask&(1<<uint(rank)) != 0 {
+			highest = append(highest, rank)
+		}
+	}
+	return highest
+}
+
+//flushScore returns the score of the best straight flush or flush in the bit set of ranks of one suit
+func flushScore(mask int, rules handRules) int {
+	if top := straightTop(mask, rules); top >= 0 {
+		return handScore(rules, straightFlush, top)
+	}
+	return handScore(rules, flush, highestRanks(mask, 5)...)
+}
+
+//unsuitedScore returns the score of the best five card hand without a flush from the number of cards of each rank
+func unsuitedScore(counts *[13]int, rules handRules) int {
+	//atLeast[n] is the bit set of the ranks held at least n times
+	var atLeast [5]int
+	for rank, count := range counts {
+		for n := 1; n <= count; n++ {
+			atLeast[n] |= 1 << uint(rank)
+		}
+	}
+	if atLeast[4] != 0 {
+		quads := highestRanks(atLeast[4], 1)[0]
+		return handScore(rules, fourOfAKind, quads, highestRanks(atLeast[1]&^(1<<uint(quads)), 1)[0])
+	}
+	if atLeast[3] != 0 {
+		trips := highestRanks(atLeast[3], 1)[0]
+		if pairs := atLeast[2] &^ (1 << uint(trips)); pairs != 0 {
+			return handScore(rules, fullHouse, trips, highestRanks(pairs, 1)[0])
+		}
+	}
+	if top := straightTop(atLeast[1], rules); top >= 0 {
+		return handScore(rules, straight, top)
+	}
+	if atLeast[3] != 0 {
+		trips := highestRanks(atLeast[3], 1)[0]
+		return handScore(rules, threeOfAKind, append([]int{trips}, highestRanks(atLeast[1]&^(1<<uint(trips)), 2)...)...)
+	}
+	if pairs := highestRanks(atLeast[2], 2); len(pairs) == 2 {
+		kicker := highestRanks(atLeast[1]&^(1<<uint(pairs[0])|1<<uint(pairs[1])), 1)
+		return handScore(rules, twoPair, append(pairs, kicker...)...)
+	} else if len(pairs) == 1 {
+		return handScore(rules, onePair, append(pairs, highestRanks(atLeast[1]&^(1<<uint(pairs[0])), 3)...)...)
+	}
+	return handScore(rules, highCard, highestRanks(atLeast[1], 5)...)
+}
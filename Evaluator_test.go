@@ -0,0 +1,130 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestTableEvaluatorMatchesPokerOnEveryFiveCardHand(t *testing.T) {
+	evaluator := DefaultEvaluator()
+	hand := make([]poker.Card, 5)
+	mismatches := 0
+	for a := 0; a < 52; a++ {
+		for b := a + 1; b < 52; b++ {
+			for c := b + 1; c < 52; c++ {
+				for d := c + 1; d < 52; d++ {
+					for e := d + 1; e < 52; e++ {
+						hand[0], hand[1], hand[2], hand[3], hand[4] = intToCard(a), intToCard(b), intToCard(c), intToCard(d), intToCard(e)
+						if evaluator.Evaluate(hand) != poker.Evaluate(hand) {
+							mismatches++
+						}
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, 0, mismatches)
+}
+
+func TestTableEvaluatorMatchesPokerOnSixAndSevenCards(t *testing.T) {
+	evaluator := DefaultEvaluator()
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 50000; i++ {
+		deck := random.Perm(52)
+		hand := make([]poker.Card, 6+i%2)
+		for card := range hand {
+			hand[card] = intToCard(deck[card])
+		}
+		if !assert.Equal(t, poker.Evaluate(hand), evaluator.Evaluate(hand), "%v", hand) {
+			return
+		}
+	}
+}
+
+func TestTableEvaluatorRanks(t *testing.T) {
+	evaluator := DefaultEvaluator()
+	cards := func(hand ...string) []poker.Card {
+		parsed := make([]poker.Card, len(hand))
+		for i := range hand {
+			parsed[i] = poker.NewCard(hand[i])
+		}
+		return parsed
+	}
+	assert.Equal(t, int32(1), evaluator.Evaluate(cards("As", "Ks", "Qs", "Js", "Ts", "2d", "2c")))
+	assert.Equal(t, int32(7462), evaluator.Evaluate(cards("7s", "5d", "4c", "3h", "2s")))
+	//the wheel is the lowest straight and a flush beats any straight in the same hand
+	assert.Equal(t, int32(1609), evaluator.Evaluate(cards("As", "2d", "3c", "4h", "5s", "Kd", "Kc")))
+	assert.Equal(t, "Flush", poker.RankString(evaluator.Evaluate(cards("2s", "3s", "4s", "5d", "6c", "9s", "Ks"))))
+}
+
+func BenchmarkTableEvaluatorSevenCards(b *testing.B) {
+	benchmarkEvaluator(b, DefaultEvaluator())
+}
+
+func BenchmarkPokerEvaluatorSevenCards(b *testing.B) {
+	benchmarkEvaluator(b, PokerEvaluator{})
+}
+
+func benchmarkEvaluator(b *testing.B, evaluator Evaluator) {
+	random := rand.New(rand.NewSource(1))
+	hands := make([][]poker.Card, 1000)
+	for i := range hands {
+		deck := random.Perm(52)
+		hands[i] = make([]poker.Card, 7)
+		for card := range hands[i] {
+			hands[i][card] = intToCard(deck[card])
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evaluator.Evaluate(hands[i%len(hands)])
+	}
+}
+
+func TestConstructTreeWithEvaluator(t *testing.T) {
+	trees := make([]*GameNode, 2)
+	traversals := make([]*Traversal, 2)
+	for i, evaluator := range []Evaluator{nil, PokerEvaluator{}} {
+		params := NewConstructionParams(0.75, 1.2)
+		params.SetEvaluator(evaluator)
+		trees[i], traversals[i] = newTurnTestTree(params)
+		runIterations(traversals[i], 5, trees[i])
+	}
+	//both evaluators give the same ranks, so the rank caches and the solves are the same
+	assertSameTree(t, trees[0], trees[1])
+	for hand := 0; hand < trees[0].NumHands(); hand++ {
+		assert.Equal(t, trees[0].Regrets(hand), trees[1].Regrets(hand))
+	}
+}
+
+func TestShortDeckEvaluatorRanks(t *testing.T) {
+	evaluator := DefaultShortDeckEvaluator()
+	holdem := DefaultEvaluator()
+	cards := func(hand ...string) []poker.Card {
+		parsed := make([]poker.Card, len(hand))
+		for i := range hand {
+			parsed[i] = poker.NewCard(hand[i])
+		}
+		return parsed
+	}
+	assert.Equal(t, int32(1), evaluator.Evaluate(cards("As", "Ks", "Qs", "Js", "Ts", "6d", "6c")))
+	//a flush beats a full house
+	flush := evaluator.Evaluate(cards("As", "Js", "9s", "7s", "6s"))
+	fullHouse := evaluator.Evaluate(cards("Ah", "Ad", "Ac", "Kd", "Kc"))
+	assert.Less(t, flush, fullHouse)
+	assert.Greater(t, holdem.Evaluate(cards("As", "Js", "9s", "7s", "6s")), holdem.Evaluate(cards("Ah", "Ad", "Ac", "Kd", "Kc")))
+	assert.Less(t, fullHouse, evaluator.Evaluate(cards("Ts", "9d", "8c", "7h", "6s")))
+	assert.Less(t, evaluator.Evaluate(cards("7h", "7d", "7c", "6d", "6c")), evaluator.Evaluate(cards("As", "Ks", "Qs", "Js", "9d")))
+	//A6789 is the lowest straight and the lowest straight flush
+	lowStraight := evaluator.Evaluate(cards("As", "6d", "7c", "8h", "9s", "Kd", "Qc"))
+	assert.Equal(t, lowStraight, evaluator.Evaluate(cards("Ad", "6d", "7c", "8h", "9s")))
+	assert.Less(t, lowStraight, evaluator.Evaluate(cards("Ts", "9d", "8c", "7h", "Ks")))
+	assert.Greater(t, lowStraight, evaluator.Evaluate(cards("Ts", "9d", "8c", "7h", "6s")))
+	assert.Less(t, evaluator.Evaluate(cards("As", "6s", "7s", "8s", "9s")), evaluator.Evaluate(cards("Ah", "Ad", "Ac", "As", "Kc")))
+	assert.Greater(t, evaluator.Evaluate(cards("As", "6s", "7s", "8s", "9s")), evaluator.Evaluate(cards("Ts", "6s", "7s", "8s", "9s")))
+	//both evaluators agree within a category
+	assert.Less(t, evaluator.Evaluate(cards("Ah", "Ad", "Kc", "Kd", "Qc")), evaluator.Evaluate(cards("Ah", "Ad", "Kc", "Kd", "Jc")))
+}
@@ -3,6 +3,7 @@ package solv
 import (
 	"fmt"
 	"math"
+	"sync"
 )
 
 type Node interface {
@@ -15,13 +16,13 @@ type GameNode struct {
 	playerNode int
 	numActions int
 	isTerminal bool
+	locked bool
+	lockFrequencies []float64
 	potSize float64
 	ipPlayerStack float64
 	oopPlayerStack float64
 
-	regrets      [][]float64
-	strategies   [][]float64
-	strategySums [][]float64
+	values nodeValues
 
 	nextNodes []Node
 }
@@ -54,19 +55,25 @@ func (node *GameNode) IPPlayerStack() float64 {
 	return node.ipPlayerStack
 }
 
-//StrategySums returns the strategy sum vector for a given hand
+//StrategySums returns the strategy sum vector for a given hand. On double precision nodes this is a view into
+//the node's storage, on single precision nodes a copy
 func (node *GameNode) StrategySums(hand int) []float64 {
-	return node.strategySums[hand]
+	return node.values.handValues(node.values.strategySums, node.values.strategySums32, hand)
 }
 
-//Regrets returns the regret sum vector for a given hand
+//Regrets returns the regret sum vector for a given hand, a view or a copy like StrategySums
 func (node *GameNode) Regrets(hand int) []float64 {
-	return node.regrets[hand]
+	return node.values.handValues(node.values.regrets, node.values.regrets32, hand)
 }
 
-//Strategy returns the strategy vector for a given hand
+//Strategy returns the strategy vector for a given hand, a view or a copy like StrategySums
 func (node *GameNode) Strategy(hand int) []float64 {
-	return node.strategies[hand]
+	return node.values.handValues(node.values.strategies, node.values.strategies32, hand)
+}
+
+//NumHands returns the number of hands in the acting player's range
+func (node *GameNode) NumHands() int {
+	return node.values.numHands()
 }
 
 //IsTerminal returns if this node is terminal
@@ -86,78 +93,240 @@ func (node *GameNode) GetNext(index int) Node {
 	return node.nextNodes[index]
 }
 
+//InitializeHandSlices allocates the regrets, strategies and strategy sums of every hand in double precision
 func (node *GameNode) InitializeHandSlices(numberHands int) {
-	node.regrets      = make([][]float64, numberHands)
-	node.strategies   = make([][]float64, numberHands)
-	node.strategySums = make([][]float64, numberHands)
-	for i := 0; i < numberHands; i++ {
-		node.regrets[i]      = make([]float64, node.numActions)
-		node.strategies[i]   = make([]float64, node.numActions)
-		node.strategySums[i] = make([]float64, node.numActions)
-	}
+	node.initializeHandValues(numberHands, false)
 }
 
-func (node *GameNode) RegretMatchAllHands() {
-	for hand := range node.regrets {
-		node.regretMinimize(hand)
-	}
+func (node *GameNode) initializeHandValues(numberHands int, singlePrecision bool) {
+	node.values = newNodeValues(numberHands, node.numActions, singlePrecision)
 }
 
-//RegretMinimize performs the strategy update for a given hand with the regret formula
-func (node *GameNode) regretMinimize(hand int) {
-	normalizingSum := node.regretStrategyUpdate(hand)
-	node.NormalizeStrategy(hand, normalizingSum)
+func (node *GameNode) RegretMatchAllHands() {
+	if node.locked {
+		return
+	}
+	for hand := 0; hand < node.NumHands(); hand++ {
+		node.values.regretMatch(hand)
+	}
 }
 
-//RegretStrategyUpdate sets the strategy for a given hand based on the regret values
-func (node *GameNode) regretStrategyUpdate(hand int) float64 {
-	normalizingSum := 0.0
-	regrets := node.Regrets(hand)
-	for i := 0; i < node.numActions; i++ {
-		node.strategies[hand][i] = math.Max(regrets[i], 0.0)
-		normalizingSum += node.strategies[hand][i]
+//regretMatchHands regret matches the given hands, the strategies of the other hands are not used by this visit
+func (node *GameNode) regretMatchHands(hands []int) {
+	if node.locked {
+		return
+	}
+	for _, hand := range hands {
+		node.values.regretMatch(hand)
 	}
-	return normalizingSum
 }
 
 //NormalizeStrategy normalizes the strategy vector for a given hand utilizing the normalizing sum
 func (node *GameNode) NormalizeStrategy(hand int, normalizingSum float64) {
 	for i := 0; i < node.numActions; i++ {
 		if normalizingSum > 0 {
-			node.strategies[hand][i] /= normalizingSum
+			node.values.setStrategy(hand, i, node.values.strategy(hand, i)/normalizingSum)
 		} else {
-			node.strategies[hand][i] = 1.0 / float64(node.numActions)
+			node.values.setStrategy(hand, i, 1.0/float64(node.numActions))
 		}
 	}
 }
 
 func (node *GameNode) RegretAndStrategySumsUpdate(trav *Traversal, reachProbability, nodeUtility []float64, actionUtility [][]float64) {
+	node.updateValues(trav, trav.activeHands(trav.Traverser), reachProbability, nodeUtility, actionUtility,
+		math.Inf(-1))
+}
+
+//updateValues is RegretAndStrategySumsUpdate for the given hands, keeping the regrets of the actions pruned below
+//pruneLimit. Below the cards of a bucket the update is collected and applied once every card was traversed
+func (node *GameNode) updateValues(trav *Traversal, hands []int, reachProbability, nodeUtility []float64,
+	actionUtility [][]float64, pruneLimit float64) {
+	if node.locked {
+		return
+	}
+	if trav.bucket != nil {
+		trav.bucket.add(node.values, hands, reachProbability, nodeUtility, actionUtility, pruneLimit)
+		return
+	}
+	positiveRegret, negativeRegret, strategyWeight := trav.discounts()
+	node.values.update(hands, reachProbability, nodeUtility, actionUtility, positiveRegret, negativeRegret,
+		strategyWeight, pruneLimit)
+}
+
+//discounts returns the factors the positive regrets, the negative regrets and the strategy sums are discounted by
+//in the current iteration
+func (trav *Traversal) discounts() (positiveRegret, negativeRegret, strategyWeight float64) {
 	iter := float64(trav.Iteration)
 	alpha := math.Pow(iter, trav.alpha)
 	beta := math.Pow(iter, trav.beta)
 	gamma := iter / (iter + 1.0)
-	positiveRegret := alpha / (alpha + 1.0)
-	negativeRegret := beta / (beta + 1.0)
-	strategyWeight := math.Pow(gamma, trav.gamma)
+	return alpha / (alpha + 1.0), beta / (beta + 1.0), math.Pow(gamma, trav.gamma)
+}
+
+//IsLocked returns if the strategy of this node is fixed by a lock, either per hand or by aggregate frequencies
+func (node *GameNode) IsLocked() bool {
+	return node.locked || node.lockFrequencies != nil
+}
+
+//LockStrategy fixes the strategy of every hand at this node, strategy[hand][action] gives the frequency of each
+//action for the hand with the same index in the acting player's range. Frequencies are normalized per hand.
+//A locked node keeps its strategy during training and the rest of the tree adapts to it
+func (node *GameNode) LockStrategy(strategy [][]float64) error {
+	if len(strategy) != node.NumHands() {
+		return fmt.Errorf("lock has %v hands, node has %v", len(strategy), node.NumHands())
+	}
+	for hand := range strategy {
+		if err := node.checkFrequencies(strategy[hand]); err != nil {
+			return fmt.Errorf("hand %v: %v", hand, err)
+		}
+	}
+	for hand := range strategy {
+		node.setLockedHandStrategy(hand, strategy[hand])
+	}
+	node.locked = true
+	node.lockFrequencies = nil
+	return nil
+}
+
+//LockRangeStrategy fixes the strategy of every hand at this node to the same action frequencies
+func (node *GameNode) LockRangeStrategy(frequencies []float64) error {
+	if err := node.checkFrequencies(frequencies); err != nil {
+		return err
+	}
+	for hand := 0; hand < node.NumHands(); hand++ {
+		node.setLockedHandStrategy(hand, frequencies)
+	}
+	node.locked = true
+	node.lockFrequencies = nil
+	return nil
+}
+
+//LockAggregateFrequencies constrains the frequencies of the whole range at this node without fixing the strategy
+//of any single hand. The node keeps regret matching and the solver decides which hands take each action, the
+//regret matched strategy is projected onto the frequencies weighted by the reach of each hand on every visit
+func (node *GameNode) LockAggregateFrequencies(frequencies []float64) error {
+	if err := node.checkFrequencies(frequencies); err != nil {
+		return err
+	}
+	sum := 0.0
+	for _, frequency := range frequencies {
+		sum += frequency
+	}
+	node.lockFrequencies = make([]float64, node.numActions)
+	for i := range frequencies {
+		node.lockFrequencies[i] = frequencies[i] / sum
+	}
+	node.locked = false
+	return nil
+}
+
+//Unlock lets the node regret match again, starting from the locked strategy as its average
+func (node *GameNode) Unlock() {
+	node.locked = false
+	node.lockFrequencies = nil
+}
 
+func (node *GameNode) checkFrequencies(frequencies []float64) error {
+	if len(frequencies) != node.numActions {
+		return fmt.Errorf("lock has %v actions, node has %v", len(frequencies), node.numActions)
+	}
+	sum := 0.0
+	for _, frequency := range frequencies {
+		if frequency < 0 {
+			return fmt.Errorf("negative action frequency %v", frequency)
+		}
+		sum += frequency
+	}
+	if sum <= 0 {
+		return fmt.Errorf("action frequencies sum to %v", sum)
+	}
+	return nil
+}
+
+//setLockedHandStrategy writes the normalized frequencies to both the current and the summed strategy so the
+//average strategy used by the best response is the locked one as well
+func (node *GameNode) setLockedHandStrategy(hand int, frequencies []float64) {
+	sum := 0.0
+	for _, frequency := range frequencies {
+		sum += frequency
+	}
+	for i := 0; i < node.numActions; i++ {
+		node.values.setStrategy(hand, i, frequencies[i]/sum)
+		node.values.setStrategySum(hand, i, frequencies[i]/sum)
+		node.values.setRegret(hand, i, 0)
+	}
+}
+
+//projectToLockFrequencies scales the current strategy until the range, weighted by reachProbability, takes each
+//action with the locked aggregate frequency while every hand still has a valid strategy. This alternately
+//normalizes the action totals and the hand totals (iterative proportional fitting), so hands keep the relative
+//preferences given by their regrets
+func (node *GameNode) projectToLockFrequencies(reachProbability, actionTotals []float64) {
+	const minimumFrequency = 1e-3
+	const maxIterations = 100
+	const tolerance = 1e-9
+
+	totalReach := 0.0
+	for hand := range reachProbability {
+		totalReach += reachProbability[hand]
+	}
+	if totalReach <= 0 {
+		return
+	}
+
+	//every hand needs some weight on each allowed action, otherwise the frequencies may be unreachable
 	for hand := range reachProbability {
 		for i := 0; i < node.numActions; i++ {
-			node.strategySums[hand][i] += reachProbability[hand] * node.strategies[hand][i]
-			node.strategySums[hand][i] *= strategyWeight
-			node.regrets[hand][i] += actionUtility[i][hand] - nodeUtility[hand]
-			//node.strategySums[hand][i] += reachProbability[hand] * node.strategies[hand][i]
-			if node.regrets[hand][i] > 0 {
-				node.regrets[hand][i] *= positiveRegret
+			if node.lockFrequencies[i] == 0 {
+				node.values.setStrategy(hand, i, 0)
 			} else {
-				node.regrets[hand][i] *= negativeRegret
+				node.values.setStrategy(hand, i, math.Max(node.values.strategy(hand, i), minimumFrequency))
+			}
+		}
+		node.normalizeHandStrategy(hand)
+	}
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		for i := range actionTotals {
+			actionTotals[i] = 0
+		}
+		for hand := range reachProbability {
+			for i := 0; i < node.numActions; i++ {
+				actionTotals[i] += reachProbability[hand] * node.values.strategy(hand, i)
 			}
 		}
+		maxError := 0.0
+		for i := range actionTotals {
+			maxError = math.Max(maxError, math.Abs(actionTotals[i]/totalReach-node.lockFrequencies[i]))
+		}
+		if maxError < tolerance {
+			break
+		}
+		for hand := range reachProbability {
+			for i := 0; i < node.numActions; i++ {
+				if actionTotals[i] > 0 {
+					scale := node.lockFrequencies[i] * totalReach / actionTotals[i]
+					node.values.setStrategy(hand, i, node.values.strategy(hand, i)*scale)
+				}
+			}
+			node.normalizeHandStrategy(hand)
+		}
+	}
+}
+
+func (node *GameNode) normalizeHandStrategy(hand int) {
+	sum := 0.0
+	for i := 0; i < node.numActions; i++ {
+		sum += node.values.strategy(hand, i)
+	}
+	for i := 0; i < node.numActions; i++ {
+		node.values.setStrategy(hand, i, node.values.strategy(hand, i)/sum)
 	}
 }
 
 func (node *GameNode) GetAverageStrategy() [][]float64 {
-	strategies := make([][]float64, len(node.strategies))
-	for hand := range node.strategySums {
+	strategies := make([][]float64, node.NumHands())
+	for hand := range strategies {
 		strategies[hand] = node.getAverageStrategy(hand)
 	}
 	return strategies
@@ -167,11 +336,11 @@ func (node *GameNode) getAverageStrategy(hand int) []float64 {
 	normalizingSum := 0.0
 	averageStrategy := make([]float64, node.numActions)
 	for i:=0; i < node.numActions; i++ {
-		normalizingSum += node.strategySums[hand][i]
+		normalizingSum += node.values.strategySum(hand, i)
 	}
 	for i:=0; i < node.numActions; i++ {
 		if normalizingSum > 0 {
-			averageStrategy[i] = node.strategySums[hand][i] / normalizingSum
+			averageStrategy[i] = node.values.strategySum(hand, i) / normalizingSum
 		} else {
 			averageStrategy[i] = 1.0 / float64(node.numActions)
 		}
@@ -189,50 +358,133 @@ func (node *GameNode) PrintNodeDetails(level int) {
 	}
 }
 
+//CFRTraversal updates the regrets and strategy sums of the subtree and returns the utility of every traverser
+//hand. The returned slice is a scratch buffer of the traversal that is only valid until the next node at the same
+//depth is traversed
 func (node *GameNode) CFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
-
-	node.RegretMatchAllHands()
-	nodeUtility := make([]float64, len(traverserReachProb))
-	if traversal.Traverser == node.playerNode {
-		node.TraverserCFR(traversal, traverserReachProb, opponentReachProb, nodeUtility)
+	level := traversal.level()
+	if node.lockFrequencies == nil {
+		node.regretMatchHands(traversal.activeHands(node.playerNode))
 	} else {
-		node.OpponentCFR(traversal, traverserReachProb, opponentReachProb, nodeUtility)
+		node.RegretMatchAllHands()
+		if traversal.Traverser == node.playerNode {
+			node.projectToLockFrequencies(traverserReachProb, level.actionTotals(node.numActions))
+		} else {
+			node.projectToLockFrequencies(opponentReachProb, level.actionTotals(node.numActions))
+		}
+	}
+	nodeUtility := level.utility(traversal.utilitySize())
+	traversal.depth++
+	if traversal.simultaneous {
+		node.simultaneousCFR(traversal, level, traverserReachProb, opponentReachProb, nodeUtility)
+	} else if traversal.Traverser == node.playerNode {
+		node.TraverserCFR(traversal, level, traverserReachProb, opponentReachProb, nodeUtility)
+	} else {
+		node.OpponentCFR(traversal, level, traverserReachProb, opponentReachProb, nodeUtility)
 	}
+	traversal.depth--
 	return nodeUtility
 }
 
-func (node *GameNode) TraverserCFR(traversal *Traversal, traverserReachProb, opponentReachProb, nodeUtility []float64) {
-	storedUtility := make([][]float64, node.numActions)
-	for i := range storedUtility {
-		storedUtility[i] = make([]float64, len(traverserReachProb))
+func (node *GameNode) TraverserCFR(traversal *Traversal, level *scratchLevel, traverserReachProb, opponentReachProb,
+	nodeUtility []float64) {
+	storedUtility := level.actionUtilities(node.numActions, len(nodeUtility))
+	nextReachProbs := level.actionReaches(node.numActions, len(traverserReachProb))
+	hands := traversal.activeHands(traversal.Traverser)
+	for i := 0; i < node.numActions; i++ {
+		node.values.actionReach(hands, nextReachProbs[i], traverserReachProb, i)
+	}
+	pruneLimit := math.Inf(-1)
+	var nextActive [][]int
+	if traversal.pruning != nil {
+		pruneLimit = traversal.pruneLimit(node, opponentReachProb)
+		nextActive = traversal.prune(node, level, hands, pruneLimit)
 	}
+	node.traverseActions(traversal, level, traverserReachProb, opponentReachProb, nextReachProbs, nextActive,
+		storedUtility)
+	for i := 0; i < node.numActions; i++ {
+		node.values.addWeightedUtility(hands, nodeUtility, storedUtility[i], i)
+	}
+
+	node.updateValues(traversal, hands, traverserReachProb, nodeUtility, storedUtility, pruneLimit)
+}
 
+func (node *GameNode) OpponentCFR(traversal *Traversal, level *scratchLevel, traverserReachProb, opponentReachProb,
+	nodeUtility []float64) {
+	storedUtility := level.actionUtilities(node.numActions, len(nodeUtility))
+	nextReachProbs := level.actionReaches(node.numActions, len(opponentReachProb))
+	opponentHands := traversal.activeHands(node.playerNode)
+	var nextActive [][]int
+	if !traversal.dense {
+		nextActive = level.actionActive(node.numActions)
+	}
 	for i := 0; i < node.numActions; i++ {
-		nextReachProb := make([]float64, len(traverserReachProb))
-		for hand := range traverserReachProb {
-			nextReachProb[hand] = node.strategies[hand][i] * traverserReachProb[hand]
+		node.values.actionReach(opponentHands, nextReachProbs[i], opponentReachProb, i)
+		if nextActive != nil {
+			reachingHands(&nextActive[i], opponentHands, nextReachProbs[i])
 		}
-		result := node.GetNext(i).CFRTraversal(traversal, nextReachProb, opponentReachProb)
-		for hand := range result {
-			storedUtility[i][hand] = result[hand]
-			nodeUtility[hand] += node.strategies[hand][i] * result[hand]
+	}
+	node.traverseActions(traversal, level, traverserReachProb, opponentReachProb, nextReachProbs, nextActive,
+		storedUtility)
+	for i := 0; i < node.numActions; i++ {
+		for _, hand := range traversal.activeHands(traversal.Traverser) {
+			nodeUtility[hand] += storedUtility[i][hand]
 		}
 	}
-
-	node.RegretAndStrategySumsUpdate(traversal, traverserReachProb, nodeUtility, storedUtility)
 }
 
-func (node *GameNode) OpponentCFR(traversal *Traversal, traverserReachProb, opponentReachProb, nodeUtility []float64) {
+//traverseActions writes the utility of the child of every action to actionUtility, nextReachProbs holds the reach
+//probabilities of the acting player after each action and nextActive, if it is not nil, the hands of the acting
+//player still reaching the child of each action. Children are handed to idle workers of the pool when there
+//are any, each child writes its own utility and the caller combines them in action order so the result does not
+//depend on which goroutine traversed which child. Folds are too cheap to be worth handing out
+func (node *GameNode) traverseActions(traversal *Traversal, level *scratchLevel, traverserReachProb,
+	opponentReachProb []float64, nextReachProbs [][]float64, nextActive [][]int, actionUtility [][]float64) {
+	active := traversal.active[node.playerNode]
 	for i := 0; i < node.numActions; i++ {
-		nextReachProb := make([]float64, len(opponentReachProb))
-		for hand := range opponentReachProb {
-			nextReachProb[hand] = node.strategies[hand][i] * opponentReachProb[hand]
+		if nextActive != nil {
+			traversal.active[node.playerNode] = nextActive[i]
 		}
-		result := node.GetNext(i).CFRTraversal(traversal, traverserReachProb, nextReachProb)
-		for hand := range result {
-			nodeUtility[hand] += result[hand]
+		travReach, oppReach := traverserReachProb, opponentReachProb
+		if node.playerNode == traversal.Traverser {
+			travReach = nextReachProbs[i]
+		} else {
+			oppReach = nextReachProbs[i]
+		}
+		level.wg.Add(1)
+		if _, fold := node.GetNext(i).(*TerminalNode); !fold && traversal.pool.hasIdleWorker() {
+			task := subtreeTask{
+				game: node,
+				traversal: traversal,
+				worker: traversal.fork(),
+				index: i,
+				travReach: travReach,
+				oppReach: oppReach,
+				subResults: actionUtility,
+				wg: &level.wg,
+			}
+			if traversal.pool.tryRun(task) {
+				continue
+			}
+			traversal.release(task.worker)
 		}
+		node.traverseAction(traversal, nil, i, travReach, oppReach, actionUtility, &level.wg)
+	}
+	traversal.active[node.playerNode] = active
+	level.wg.Wait()
+}
+
+//traverseAction traverses the child of action with the forked worker if it was handed to one, or otherwise with
+//the traversal of the game node itself
+func (node *GameNode) traverseAction(traversal, worker *Traversal, action int, traverserReachProb,
+	opponentReachProb []float64, actionUtility [][]float64, wg *sync.WaitGroup) {
+	defer wg.Done()
+	if worker == nil {
+		copy(actionUtility[action], node.GetNext(action).CFRTraversal(traversal, traverserReachProb, opponentReachProb))
+		return
 	}
+	copy(actionUtility[action], node.GetNext(action).CFRTraversal(worker, traverserReachProb, opponentReachProb))
+	traversal.release(worker)
 }
 
 //BestResponse traverses the game tree and finds the ev of the best response strategy for the responding player
@@ -254,22 +506,34 @@ func (node *GameNode) OverallBestResponse(traversal *Traversal, responderRelativ
 //BestResponse calculates the best response ev for a specific hand through a recursive tree search
 func (node *GameNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
 	if node.playerNode == traversal.Traverser {
-		bestEvs := make([]float64, len(node.strategies))
+		if traversal.profile != nil && traversal.profile.evaluating {
+			return node.evaluateProfile(traversal, opponentReachProb)
+		}
+		bestEvs := make([]float64, node.NumHands())
+		bestActions := make([]int, node.NumHands())
 		for i := range node.nextNodes {
 			nextEv := node.nextNodes[i].BestResponse(traversal, opponentReachProb)
 			for hand := range bestEvs {
 				if i == 0 || nextEv[hand] > bestEvs[hand] {
 					bestEvs[hand] = nextEv[hand]
+					bestActions[hand] = i
 				}
 			}
 		}
+		if traversal.profile != nil {
+			traversal.profile.recordResponse(node, bestActions, opponentReachProb)
+		}
 		return bestEvs
 	} else {
 		nodeEv := make([]float64, len(traversal.Ranges[traversal.Traverser]))
-		averageStrategies := make([][]float64, len(node.strategies))
+		averageStrategies := make([][]float64, node.NumHands())
 
 		for i := range opponentReachProb {
-			averageStrategies[i] = node.getAverageStrategy(i)
+			if traversal.profile != nil {
+				averageStrategies[i] = traversal.profile.strategy(node, i)
+			} else {
+				averageStrategies[i] = node.getAverageStrategy(i)
+			}
 		}
 
 		for i := range node.nextNodes {
@@ -287,5 +551,28 @@ func (node *GameNode) BestResponse(traversal *Traversal, opponentReachProb []flo
 	}
 }
 
-
-
+//evaluateProfile returns the ev of the traverser playing the strategy of the profile at this node instead of the
+//best response, recording how much each hand loses compared to its best action
+func (node *GameNode) evaluateProfile(traversal *Traversal, opponentReachProb []float64) []float64 {
+	nodeEv := make([]float64, node.NumHands())
+	bestEvs := make([]float64, node.NumHands())
+	strategies := make([][]float64, node.NumHands())
+	for hand := range strategies {
+		strategies[hand] = traversal.profile.strategy(node, hand)
+	}
+	for i := range node.nextNodes {
+		nextEv := node.nextNodes[i].BestResponse(traversal, opponentReachProb)
+		for hand := range nodeEv {
+			nodeEv[hand] += strategies[hand][i] * nextEv[hand]
+			if i == 0 || nextEv[hand] > bestEvs[hand] {
+				bestEvs[hand] = nextEv[hand]
+			}
+		}
+	}
+	losses := make([]float64, len(nodeEv))
+	for hand := range losses {
+		losses[hand] = bestEvs[hand] - nodeEv[hand]
+	}
+	traversal.profile.recordLosses(node, losses)
+	return nodeEv
+}
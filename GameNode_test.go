@@ -1 +1,120 @@
 package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var riverBoard = []poker.Card{poker.NewCard("Ac"), poker.NewCard("7s"), poker.NewCard("5s"),
+	poker.NewCard("3d"), poker.NewCard("2h")}
+
+var turnBoard = []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c")}
+
+func newTurnTestTree(params *ConstructionParams) (*GameNode, *Traversal) {
+	oop := RemoveConflicts(HandsStringToHandRange("TT+, AQs+, KQs, AK, 98s"), turnBoard)
+	ip := RemoveConflicts(HandsStringToHandRange("99+, ATs+, KTs+, QJs, AQ+, 43s"), turnBoard)
+	return mustConstructTree(100, 150, params, ip, oop, turnBoard), NewTraversal(oop, ip)
+}
+
+func newRiverTestTree(params *ConstructionParams) (*GameNode, *Traversal) {
+	oop := RemoveConflicts(HandsStringToHandRange("JJ, 44"), riverBoard)
+	ip := RemoveConflicts(HandsStringToHandRange("QQ, T9s, 64s"), riverBoard)
+	return mustConstructTree(400, 4000, params, ip, oop, riverBoard), NewTraversal(oop, ip)
+}
+
+//mustConstructTree is ConstructTree for the trees of the tests, which are always valid
+func mustConstructTree(startingPot, startingStack float64, params *ConstructionParams, ipHands, oopHands Range,
+	board []poker.Card) *GameNode {
+	root, err := ConstructTree(startingPot, startingStack, params, ipHands, oopHands, board)
+	if err != nil {
+		panic(err)
+	}
+	return root
+}
+
+//runIterations performs the same updates as Train without the best response calculations
+func runIterations(traversal *Traversal, iterations int, root *GameNode) {
+	ip := convertRangeToFloatSlice(traversal.Ranges[1])
+	oop := convertRangeToFloatSlice(traversal.Ranges[0])
+	for i := 0; i < iterations; i++ {
+		traversal.Iteration = traversal.startIteration + i
+		traversal.Traverser = 0
+		root.CFRTraversal(traversal, oop, ip)
+		traversal.Traverser = 1
+		root.CFRTraversal(traversal, ip, oop)
+	}
+}
+
+func TestGameNode_LockRangeStrategy(t *testing.T) {
+	root, trav := newRiverTestTree(NewConstructionParams(1.0, 1.2))
+	assert.NotNil(t, root.LockRangeStrategy([]float64{1.0}))
+	assert.NotNil(t, root.LockRangeStrategy([]float64{0, 0}))
+	assert.Nil(t, root.LockRangeStrategy([]float64{3, 1}))
+	assert.True(t, root.IsLocked())
+
+	runIterations(trav, 50, root)
+	average := root.GetAverageStrategy()
+	for hand := range average {
+		assert.InDeltaSlice(t, []float64{0.75, 0.25}, average[hand], 0.0001)
+		assert.InDeltaSlice(t, []float64{0.75, 0.25}, root.Strategy(hand), 0.0001)
+		assert.Equal(t, []float64{0, 0}, root.Regrets(hand))
+	}
+
+	root.Unlock()
+	runIterations(trav, 1, root)
+	assert.False(t, root.IsLocked())
+}
+
+func TestGameNode_LockStrategy(t *testing.T) {
+	root, trav := newRiverTestTree(NewConstructionParams(1.0, 1.2))
+	bet := root.GetNext(1).(*GameNode)
+	assert.NotNil(t, bet.LockStrategy(make([][]float64, 1)))
+
+	//ip calls with the first hand and folds everything else
+	strategy := make([][]float64, len(trav.Ranges[1]))
+	for hand := range strategy {
+		strategy[hand] = make([]float64, bet.NumActions())
+		if hand == 0 {
+			strategy[hand][0] = 1
+		} else {
+			strategy[hand][1] = 1
+		}
+	}
+	assert.Nil(t, bet.LockStrategy(strategy))
+
+	runIterations(trav, 100, root)
+	average := bet.GetAverageStrategy()
+	for hand := range average {
+		assert.InDeltaSlice(t, strategy[hand], average[hand], 0.0001)
+	}
+	//the bet is never called by a worse hand so oop bets every hand
+	for _, oopStrategy := range root.GetAverageStrategy() {
+		assert.True(t, oopStrategy[1] > 0.9)
+	}
+}
+
+func TestGameNode_LockAggregateFrequencies(t *testing.T) {
+	root, trav := newRiverTestTree(NewConstructionParams(1.0, 1.2))
+	assert.NotNil(t, root.LockAggregateFrequencies([]float64{-1, 2}))
+	assert.Nil(t, root.LockAggregateFrequencies([]float64{0.8, 0.2}))
+	assert.True(t, root.IsLocked())
+
+	runIterations(trav, 200, root)
+	average := root.GetAverageStrategy()
+	aggregate := make([]float64, root.NumActions())
+	totalCombos := 0.0
+	differs := false
+	for hand, combo := range trav.Ranges[0] {
+		for action := range aggregate {
+			aggregate[action] += combo.Combos * average[hand][action]
+		}
+		totalCombos += combo.Combos
+		differs = differs || average[hand][0]-average[0][0] > 0.1 || average[0][0]-average[hand][0] > 0.1
+	}
+	for action := range aggregate {
+		aggregate[action] /= totalCombos
+	}
+	assert.InDeltaSlice(t, []float64{0.8, 0.2}, aggregate, 0.001)
+	assert.True(t, differs, "the solver should pick which hands take each action")
+}
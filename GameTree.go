@@ -1,27 +1,55 @@
 package solv
 
 import (
+	"errors"
 	"fmt"
 	"github.com/chehsunliu/poker"
 	"math"
+	"sync"
+	"time"
 )
 
-//Traversal contains the index of the current traverser, the ranges, and two caches mapping
-//a hand to the index in the opponents range. This cache is used for terminal node utility eval
+//Traversal contains the index of the current traverser, the ranges, and two caches mapping the ComboIndex of
+//a hand to its index in each range (-1 if it is not in the range). These caches and the combo and card indexes
+//of every hand of the ranges are used for terminal node utility eval. active holds the hands of each player that
+//still need work below the current node, nil meaning the whole range
 type Traversal struct {
 	Traverser int
 	Ranges [2]Range
-	IndexCaches [2]map[Hand]int
+	IndexCaches [2][]int
+	comboIndexes [2][]int
+	cardIndexes [2][][2]int
 	Iteration int
 	alpha float64
 	beta float64
 	gamma float64
+	profile *strategyProfile
+	startIteration int
+	levels []*scratchLevel
+	depth int
+	forks *sync.Pool
+	pool *WorkerPool
+	active [2][]int
+	dense bool
+	pruning *regretPruning
+	simultaneous bool
+	sampling *chanceSampling
+	chipPayoffs bool
+	bucket *bucketUpdates
 }
 
 //ConstructionParams - used for construction of the game tree, the i-th index of a given bets array gives that
 //bet number i.e. oopFlopBets[1] gives a slice with the bets used when responding to a one bet sequence prior
 //allInCutoff will make the only bet all in if the bettor's stack is smaller than that % of the pot.
 //The default bet is a % of the pot used when there are no specific bets for that action sequence.
+//Isomorphic turn and river cards share their subtree unless disableIsomorphism is set, all in nodes use
+//precomputed equity tables where they are faster than evaluating every runout unless disableEquityTables is set.
+//singlePrecision stores the regrets and strategies of the tree as float32. The subtrees of dealt cards are built
+//on pool, or the default worker pool if it is nil. Showdowns are ranked with the ranks of rankCache, which can be
+//shared between trees, or otherwise with a new RankCache using evaluator, or the default evaluator if it is nil.
+//The turn and river cards of a bucket of abstraction share their strategies. Streets after leafStreet are not
+//built when leafEstimator is set, LeafNodes estimate them instead. The winner of every pot pays rake, and pots are
+//valued in tournament equity when icm is set. Cards are dealt from the deck of variant.
 type ConstructionParams struct {
 	allInCutoff float64
 	defaultBet float64
@@ -31,25 +59,44 @@ type ConstructionParams struct {
 	oopTurnBets [][]float64
 	ipRiverBets [][]float64
 	oopRiverBets [][]float64
+	disableIsomorphism bool
+	disableEquityTables bool
+	singlePrecision bool
+	pool *WorkerPool
+	evaluator Evaluator
+	rankCache *RankCache
+	abstraction CardAbstraction
+	leafStreet int
+	leafEstimator LeafEstimator
+	rake Rake
+	icm *icmPayoffs
+	variant Variant
 }
 
 func NewTraversal(oopRange, ipRange Range) *Traversal {
 	var rng [2]Range
-	var indexes [2]map[Hand]int
-	indexes[0] = make(map[Hand]int)
-	indexes[1] = make(map[Hand]int)
+	var indexes [2][]int
+	var comboIndexes [2][]int
+	var cardIndexes [2][][2]int
 	rng[0] = oopRange
 	rng[1] = ipRange
-	for index := range oopRange {
-		indexes[0][oopRange[index].Hand] = index
-	}
-	for index := range ipRange {
-		indexes[1][ipRange[index].Hand] = index
+	for player := range rng {
+		indexes[player] = rng[player].ComboIndexes()
+		comboIndexes[player] = make([]int, len(rng[player]))
+		cardIndexes[player] = make([][2]int, len(rng[player]))
+		for index := range rng[player] {
+			comboIndexes[player][index] = rng[player][index].Hand.ComboIndex()
+			cardIndexes[player][index] = rng[player][index].Hand.CardIndexes()
+		}
 	}
 	return &Traversal{
 		Traverser: 0,
 		Ranges:    rng,
 		IndexCaches: indexes,
+		comboIndexes: comboIndexes,
+		cardIndexes: cardIndexes,
+		forks: newForkPool(),
+		pool: DefaultWorkerPool(),
 		Iteration: 0,
 		alpha: 1.5,
 		beta: 0.0,
@@ -57,10 +104,27 @@ func NewTraversal(oopRange, ipRange Range) *Traversal {
 	}
 }
 
+//SetWorkerPool sets the pool running the subtrees of dealt cards, traversals of solves running at the same time
+//can share a pool to bound the goroutines they use together. A pool with one worker traverses serially
+func (traversal *Traversal) SetWorkerPool(pool *WorkerPool) {
+	traversal.pool = pool
+}
+
+//SetSparse sets if the traversal skips the hands that have no reach or are blocked by the board, which gives the
+//same results as going over the whole range at every node. This is enabled by default
+func (traversal *Traversal) SetSparse(enabled bool) {
+	traversal.dense = !enabled
+}
+
 func (traversal* Traversal) GetRange(player int) Range {
 	return traversal.Ranges[player]
 }
 
+//HandIndex returns the index of hand in the range of player, or -1 if it is not in the range
+func (traversal *Traversal) HandIndex(player int, hand Hand) int {
+	return traversal.IndexCaches[player][hand.ComboIndex()]
+}
+
 func NewConstructionParams(defaultBet, allInCutoff float64) *ConstructionParams {
 	return &ConstructionParams{
 		defaultBet: defaultBet,
@@ -68,19 +132,218 @@ func NewConstructionParams(defaultBet, allInCutoff float64) *ConstructionParams
 	}
 }
 
+//SetIsomorphism sets if turn and river cards that are the same up to a suit permutation of the boards and ranges
+//share a single subtree, this is enabled by default
+func (params *ConstructionParams) SetIsomorphism(enabled bool) {
+	params.disableIsomorphism = !enabled
+}
+
+//SetEquityTables sets if all in nodes before the river may use a precomputed EquityTable of their board instead
+//of evaluating a showdown on every runout, this is enabled by default
+func (params *ConstructionParams) SetEquityTables(enabled bool) {
+	params.disableEquityTables = !enabled
+}
+
+//SetSinglePrecision sets if the regrets, strategies and strategy sums of the tree are stored as float32 instead of
+//float64, which halves the memory of the tree at the cost of some precision
+func (params *ConstructionParams) SetSinglePrecision(enabled bool) {
+	params.singlePrecision = enabled
+}
+
+//SetWorkerPool sets the pool the subtrees of dealt cards are built on, the tree is the same whatever the pool
+func (params *ConstructionParams) SetWorkerPool(pool *WorkerPool) {
+	params.pool = pool
+}
+
+func (params *ConstructionParams) workerPool() *WorkerPool {
+	if params.pool == nil {
+		return DefaultWorkerPool()
+	}
+	return params.pool
+}
+
+//SetEvaluator sets the Evaluator ranking the hands at showdowns of the tree, it is not used when a RankCache is
+//set since the cache ranks boards with its own evaluator
+func (params *ConstructionParams) SetEvaluator(evaluator Evaluator) {
+	params.evaluator = evaluator
+}
+
+//SetRankCache sets the RankCache the showdowns of the tree are ranked with, trees constructed with the same cache
+//only evaluate each river board once
+func (params *ConstructionParams) SetRankCache(cache *RankCache) {
+	params.rankCache = cache
+}
+
+//SetCardAbstraction sets the CardAbstraction bucketing the turn and river cards of the tree, or no abstraction if
+//it is nil. Cards are not grouped by suit isomorphism at chance nodes with buckets, since the buckets already
+//share the strategies of the cards. Exploitability is still exact, so it measures the error of the abstraction
+func (params *ConstructionParams) SetCardAbstraction(abstraction CardAbstraction) {
+	params.abstraction = abstraction
+}
+
+//SetDepthLimit ends the tree after street (1 flop, 2 turn) with a LeafNode wherever the next card would be dealt,
+//its utilities are estimated by estimator. All ins are still evaluated on every runout. A nil estimator builds
+//every street again. Leaf estimators value pots in chips, so trees valued with an ICM cannot be depth limited
+func (params *ConstructionParams) SetDepthLimit(street int, estimator LeafEstimator) error {
+	if estimator != nil && params.icm != nil {
+		return errors.New("a tree valued with an icm cannot be depth limited")
+	}
+	params.leafStreet = street
+	params.leafEstimator = estimator
+	return nil
+}
+
+//SetRake sets the rake taken from the pots of the tree at folds, showdowns and leaves, the zero Rake takes none.
+//The payoffs of raked trees do not sum to zero, Exploitability and EvaluateStrategy account for the rake
+func (params *ConstructionParams) SetRake(rake Rake) {
+	params.rake = rake
+}
+
+//SetICM values the pots of the tree in tournament equity with icm instead of in chips, or in chips if icm is nil, so
+//the solver maximizes the equity of the players. Pots are raked before they are valued. All ins evaluate every
+//runout since the equity tables only count wins against losses, and a depth limited tree cannot be valued with an
+//ICM since the leaf estimators value pots in chips. TournamentEVs reports the evs in both chips and equity
+func (params *ConstructionParams) SetICM(icm *ICM) error {
+	if icm == nil {
+		params.icm = nil
+		return nil
+	}
+	if params.leafEstimator != nil {
+		return errors.New("a depth limited tree cannot be valued with an icm")
+	}
+	if err := icm.Validate(); err != nil {
+		return err
+	}
+	params.icm = newICMPayoffs(icm)
+	return nil
+}
+
+//potPayoffs returns the icm payoffs of pot with rake taken from it, or nil if the tree is valued in chips
+func (params *ConstructionParams) potPayoffs(pot, rake float64) *[2]payoff {
+	if params.icm == nil {
+		return nil
+	}
+	return params.icm.pot(pot, rake)
+}
+
+//SetVariant sets the variant the tree is played in, hold'em by default. Cards are dealt from the deck of the
+//variant and showdowns are ranked with its evaluator unless an evaluator or RankCache is set, which must then rank
+//hands by the rules of the variant. The ranges should come from Variant.HandRange, ConstructTree rejects ranges,
+//boards, evaluators and caches of another variant
+func (params *ConstructionParams) SetVariant(variant Variant) {
+	params.variant = variant
+}
+
+//SetBets sets the bets of player (0 oop, 1 ip) on street (1 flop, 2 turn, 3 river), see ConstructionParams
+func (params *ConstructionParams) SetBets(street, player int, bets [][]float64) {
+	switch street {
+	case 1:
+		if player == 0 {
+			params.oopFlopBets = bets
+		} else {
+			params.ipFlopBets = bets
+		}
+	case 2:
+		if player == 0 {
+			params.oopTurnBets = bets
+		} else {
+			params.ipTurnBets = bets
+		}
+	case 3:
+		if player == 0 {
+			params.oopRiverBets = bets
+		} else {
+			params.ipRiverBets = bets
+		}
+	}
+}
+
+//ConstructTree builds the game tree of the ranges on board with params. It returns an error if the RankCache or
+//evaluator of params ranks hands by the rules of another variant than the one of params, or if a hand of the
+//ranges or a card of the board is not in the deck of the variant
 func ConstructTree(startingPot, startingStack float64, params *ConstructionParams,
-					ipHands, oopHands Range, board []poker.Card) *GameNode {
+					ipHands, oopHands Range, board []poker.Card) (*GameNode, error) {
+	if err := params.checkVariant(ipHands, oopHands, board); err != nil {
+		return nil, err
+	}
 	root := NewGameNode(0, startingPot, startingStack, startingStack)
 	cache := NewRiverEvaluationCache(oopHands, ipHands)
+	cache.variant = params.variant
+	cache.startingCards = len(board)
+	if params.rankCache != nil {
+		cache.ranks = params.rankCache
+	} else if params.evaluator != nil {
+		cache.ranks = NewRankCache(params.evaluator)
+	} else if params.variant != Holdem {
+		cache.ranks = NewRankCache(params.variant.Evaluator())
+	}
+	cache.reserveRunouts(board)
 	addSuccessorNodes(root, 0, params, board, cache)
-	initializeNodeHandSlices(root, ipHands, oopHands)
-	return root
+	initializeNodeHandSlices(root, ipHands, oopHands, params)
+	return root, nil
+}
+
+//checkVariant returns an error if the showdowns of params would not be ranked by the rules of the variant, or if
+//the ranges or board hold a card the deck of the variant does not
+func (params *ConstructionParams) checkVariant(ipHands, oopHands Range, board []poker.Card) error {
+	if params.rankCache != nil && params.rankCache.Variant() != params.variant {
+		return fmt.Errorf("rank cache ranks %v hands, the tree is %v", params.rankCache.Variant(), params.variant)
+	}
+	if params.rankCache == nil && params.evaluator != nil && evaluatorVariant(params.evaluator) != params.variant {
+		return fmt.Errorf("evaluator ranks %v hands, the tree is %v", evaluatorVariant(params.evaluator),
+			params.variant)
+	}
+	for _, card := range board {
+		if !params.variant.InDeck(card) {
+			return fmt.Errorf("board card %v is not in the %v deck", card, params.variant)
+		}
+	}
+	for player, hands := range [2]Range{oopHands, ipHands} {
+		for _, combo := range hands {
+			if !params.variant.InDeck(combo.Hand[0]) || !params.variant.InDeck(combo.Hand[1]) {
+				return fmt.Errorf("hand %v of player %v is not in the %v deck", combo.Hand, player, params.variant)
+			}
+		}
+	}
+	return nil
 }
 
 func OutputTree(root Node) {
 	root.PrintNodeDetails(0)
 }
 
+//NodeAtPath follows path from the root and returns the node it ends at. Each entry of path is the index of the
+//child to take, at a GameNode this is the action index and at a ChanceNode it is the index into the dealt cards
+func NodeAtPath(root *GameNode, path []int) (Node, error) {
+	var current Node = root
+	for depth, index := range path {
+		next, err := nextOnPath(current, index, depth)
+		if err != nil {
+			return nil, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+func nextOnPath(current Node, index, depth int) (Node, error) {
+	switch node := current.(type) {
+	case *GameNode:
+		if index < 0 || index >= len(node.nextNodes) {
+			return nil, fmt.Errorf("action %v at depth %v is out of range, node has %v actions",
+				index, depth, len(node.nextNodes))
+		}
+		return node.nextNodes[index], nil
+	case *ChanceNode:
+		if index < 0 || index >= len(node.nextNodes) {
+			return nil, fmt.Errorf("card %v at depth %v is out of range, node deals %v cards",
+				index, depth, len(node.nextNodes))
+		}
+		return node.nextNodes[index], nil
+	}
+	return nil, fmt.Errorf("path continues past a leaf node at depth %v", depth)
+}
+
 func addSuccessorNodes(root *GameNode, betNumber int, params *ConstructionParams,
 						board []poker.Card, cache *RiverEvaluationCache) {
 	var street int
@@ -111,35 +374,75 @@ func createNextCallCheckAndFoldNodes(root *GameNode, betNumber, street int, para
 	//go to showdown if this is the river
 	if street == 3 {
 		next := NewShowdownNode(root.potSize + lastBetSize, root.playerNode, board, cache)
+		rake := params.rake.Amount(next.potSize)
+		next.setRake(rake)
+		next.icm = params.potPayoffs(next.potSize, rake)
 		root.AddNextNode(next)
 		index := cache.InsertBoard(board)
 		next.cacheIndex = index
 	} else if callStacks == 0 {
 		next := NewAllInShowdownNode(root.potSize + lastBetSize, street, cache)
-		runouts := constructPossibleRunouts(board, cache)
-		for _, runout := range runouts {
-			showdown := NewShowdownNode(next.potSize, root.playerNode, runout, cache)
-			index := cache.InsertBoard(runout)
-			showdown.cacheIndex = index
-			next.AddNextNode(showdown)
+		next.setRake(params.rake.Amount(next.potSize), board)
+		if !params.disableEquityTables && params.icm == nil &&
+			equityTableCheaperThanRunouts(board, cache.oopRange, cache.ipRange, cache.variant) {
+			next.table = cache.EquityTable(board)
+		} else {
+			runouts := constructPossibleRunouts(board, cache.variant)
+			for _, runout := range runouts {
+				showdown := NewShowdownNode(next.potSize, root.playerNode, runout, cache)
+				rake := params.rake.Amount(next.potSize)
+				showdown.setRake(rake)
+				showdown.icm = params.potPayoffs(next.potSize, rake)
+				index := cache.InsertBoard(runout)
+				showdown.cacheIndex = index
+				next.AddNextNode(showdown)
+			}
 		}
 		root.AddNextNode(next)
+	} else if params.leafEstimator != nil && street >= params.leafStreet {
+		leaf := NewLeafNode(root.potSize + lastBetSize, callStacks, board, cache, params.leafEstimator)
+		leaf.setRake(params.rake.Amount(leaf.potSize))
+		root.AddNextNode(leaf)
 	} else {
-		next := NewChanceNode(root.potSize + lastBetSize, callStacks, board, street)
-		for _, card := range next.nextCards {
-			newBoard := make([]poker.Card, len(board))
+		next := NewChanceNode(root.potSize + lastBetSize, callStacks, board, street, cache.variant)
+		ranges := [2]Range{cache.oopRange, cache.ipRange}
+		if params.abstraction != nil {
+			next.setBuckets(params.abstraction.Buckets(board, next.nextCards, ranges, cache.ranks))
+		} else if !params.disableIsomorphism {
+			next.setIsomorphs(findCardIsomorphisms(next.nextCards, board, cache.startingCards, ranges))
+		}
+		//the subtrees of the cards are built in parallel, each one is placed at the index of its card so the tree
+		//does not depend on the order they finish in
+		next.nextNodes = make([]Node, len(next.nextCards))
+		var wg sync.WaitGroup
+		for index, card := range next.nextCards {
+			if isomorphism := next.isomorphicCard(index); isomorphism != nil {
+				next.nextNodes[index] = next.nextNodes[isomorphism.canonical]
+				continue
+			}
+			newBoard := make([]poker.Card, len(board), len(board)+1)
 			copy(newBoard, board)
 			newBoard = append(newBoard, card)
 			gn := NewGameNode(0, next.potSize, next.ipPlayerStack, next.oopPlayerStack)
-			next.AddNextNode(gn)
-			addSuccessorNodes(gn, 0, params, newBoard, cache)
+			next.nextNodes[index] = gn
+			wg.Add(1)
+			params.workerPool().run(subtreeTask{
+				construct: func() {
+					addSuccessorNodes(gn, 0, params, newBoard, cache)
+				},
+				wg: &wg,
+			})
 		}
+		wg.Wait()
 		root.AddNextNode(next)
 	}
 	if betNumber > 0 {
 		foldStacks := math.Max(root.ipPlayerStack, root.oopPlayerStack)
 		fold := NewTerminalNode(NewGameNode(root.playerNode ^ 1, root.potSize - lastBetSize, foldStacks, foldStacks))
 		fold.board = board
+		rake := params.rake.Amount(fold.potSize)
+		fold.setRake(rake)
+		fold.icm = params.potPayoffs(fold.potSize, rake)
 		root.AddNextNode(fold)
 	}
 }
@@ -208,54 +511,157 @@ func getCurrentBets(street, player, betNumber int, params *ConstructionParams) [
 	return []float64{params.defaultBet}
 }
 
-func initializeNodeHandSlices(toInit *GameNode, ipHands, oopHands Range) {
+func initializeNodeHandSlices(toInit *GameNode, ipHands, oopHands Range, params *ConstructionParams) {
 	toInit.numActions = len(toInit.nextNodes)
 	if toInit.playerNode == 1 {
-		toInit.InitializeHandSlices(len(ipHands))
+		toInit.initializeHandValues(len(ipHands), params.singlePrecision)
 	} else {
-		toInit.InitializeHandSlices(len(oopHands))
+		toInit.initializeHandValues(len(oopHands), params.singlePrecision)
 	}
 	for index := range toInit.nextNodes {
 		if node, ok := toInit.nextNodes[index].(*GameNode); ok {
-			initializeNodeHandSlices(node, ipHands, oopHands)
+			initializeNodeHandSlices(node, ipHands, oopHands, params)
 		}
 		if node, ok := toInit.nextNodes[index].(*ChanceNode); ok {
-			for chanceNextIndex := range node.nextNodes {
-				if nextNode, ok := node.nextNodes[chanceNextIndex].(*GameNode); ok {
-					initializeNodeHandSlices(nextNode, ipHands, oopHands)
-				}
-			}
+			initializeChanceNodeHandSlices(node, ipHands, oopHands, params)
 		}
 	}
 }
 
-func Train(traversal *Traversal, iterations int, treeRoot *GameNode) {
-	ip := convertRangeToFloatSlice(traversal.Ranges[1])
-	oop := convertRangeToFloatSlice(traversal.Ranges[0])
+//initializeChanceNodeHandSlices initializes the subtrees of the cards dealt at node in parallel, cards sharing the
+//strategies of their bucket are given them once the first card of the bucket has them
+func initializeChanceNodeHandSlices(node *ChanceNode, ipHands, oopHands Range, params *ConstructionParams) {
+	var wg sync.WaitGroup
+	for chanceNextIndex := range node.nextNodes {
+		if node.isomorphicCard(chanceNextIndex) != nil || node.sharesValues(chanceNextIndex) {
+			continue
+		}
+		if nextNode, ok := node.nextNodes[chanceNextIndex].(*GameNode); ok {
+			wg.Add(1)
+			params.workerPool().run(subtreeTask{
+				construct: func() {
+					initializeNodeHandSlices(nextNode, ipHands, oopHands, params)
+				},
+				wg: &wg,
+			})
+		}
+	}
+	wg.Wait()
+	for chanceNextIndex := range node.nextNodes {
+		if !node.sharesValues(chanceNextIndex) {
+			continue
+		}
+		nextNode := node.nextNodes[chanceNextIndex].(*GameNode)
+		shared := node.nextNodes[node.bucketCards(chanceNextIndex)[0]].(*GameNode)
+		wg.Add(1)
+		params.workerPool().run(subtreeTask{
+			construct: func() {
+				shareNodeHandSlices(nextNode, shared, ipHands, oopHands, params)
+			},
+			wg: &wg,
+		})
+	}
+	wg.Wait()
+}
+
+//shareNodeHandSlices gives every game node of the street of toShare the values of the game node at the same place
+//under shared, which was built with the same bets. The chance nodes ending the street get values of their own
+func shareNodeHandSlices(toShare, shared *GameNode, ipHands, oopHands Range, params *ConstructionParams) {
+	toShare.numActions = len(toShare.nextNodes)
+	toShare.values = shared.values
+	for index := range toShare.nextNodes {
+		switch node := toShare.nextNodes[index].(type) {
+		case *GameNode:
+			shareNodeHandSlices(node, shared.nextNodes[index].(*GameNode), ipHands, oopHands, params)
+		case *ChanceNode:
+			initializeChanceNodeHandSlices(node, ipHands, oopHands, params)
+		}
+	}
+}
+
+//Exploitability returns the best response ev of both players against the average strategy of the tree and the
+//resulting exploitability in percent of the starting pot, the average gain of the best responses over the evs of
+//the average strategies. Those evs sum to zero unless the tree takes rake or is valued with an ICM, so they are
+//only computed for such trees. With an ICM the evs are tournament equity and the exploitability is the equity
+//given up per 100 chips of the starting pot
+func Exploitability(traversal *Traversal, treeRoot *GameNode) (oopBestResponse, ipBestResponse, exploitability float64) {
 	ipRelativeProb := RangeRelativeProbabilities(traversal.Ranges[1], traversal.Ranges[0])
 	oopRelativeProb := RangeRelativeProbabilities(traversal.Ranges[0], traversal.Ranges[1])
 
+	traverser := traversal.Traverser
 	traversal.Traverser = 0
-	oopBestResponse := treeRoot.OverallBestResponse(traversal, oopRelativeProb)
+	oopBestResponse = treeRoot.OverallBestResponse(traversal, oopRelativeProb)
 	traversal.Traverser = 1
-	ipBestResponse := treeRoot.OverallBestResponse(traversal, ipRelativeProb)
+	ipBestResponse = treeRoot.OverallBestResponse(traversal, ipRelativeProb)
+	traversal.Traverser = traverser
 
+	gain := oopBestResponse + ipBestResponse
+	if !zeroSum(treeRoot) {
+		gain -= strategyValue(traversal, treeRoot, 0) + strategyValue(traversal, treeRoot, 1)
+	}
+	exploitability = gain / 2 / treeRoot.potSize * 100
+	return oopBestResponse, ipBestResponse, exploitability
+}
+
+//zeroSum returns if the payoffs of both players sum to zero everywhere below current, which they do unless the
+//tree takes rake or is valued with an ICM
+func zeroSum(current Node) bool {
+	switch node := current.(type) {
+	case *GameNode:
+		for _, next := range node.nextNodes {
+			if !zeroSum(next) {
+				return false
+			}
+		}
+	case *ChanceNode:
+		for index, next := range node.nextNodes {
+			if !node.sharesCanonical(index) && !zeroSum(next) {
+				return false
+			}
+		}
+	case *TerminalNode:
+		return node.rakeUtility == 0 && node.icm == nil
+	case *ShowdownNode:
+		return node.rakeUtility == 0 && node.icm == nil
+	case *AllInShowdownNode:
+		for _, next := range node.nextNodes {
+			if !zeroSum(next) {
+				return false
+			}
+		}
+		return node.rakeUtility == 0
+	case *LeafNode:
+		return node.rakeUtility == 0
+	}
+	return true
+}
+
+func Train(traversal *Traversal, iterations int, treeRoot *GameNode) {
+	ip := convertRangeToFloatSlice(traversal.Ranges[1])
+	oop := convertRangeToFloatSlice(traversal.Ranges[0])
+
+	oopBestResponse, ipBestResponse, exploitability := Exploitability(traversal, treeRoot)
 	fmt.Printf("Iteration 0 oop BR: %v ip BR: %v exploitability = ", oopBestResponse, ipBestResponse)
-	fmt.Printf("%v percent of the pot\n", (oopBestResponse + ipBestResponse) / 2 / treeRoot.potSize * 100)
+	fmt.Printf("%v percent of the pot\n", exploitability)
 
+	var reportedActions, reportedPruned int64
+	//the time spent on iterations without the best responses, to compare exploitability per second
+	var training time.Duration
 	for i := 0; i <= iterations; i++ {
-		traversal.Iteration = i
-		traversal.Traverser = 0
-		treeRoot.CFRTraversal(traversal, oop, ip)
-		traversal.Traverser = 1
-		treeRoot.CFRTraversal(traversal, ip, oop)
+		start := time.Now()
+		traversal.Iteration = traversal.startIteration + i
+		traversal.iterate(treeRoot, oop, ip)
+		training += time.Since(start)
 		if  i > 0 && i % 25 == 0 {
-			traversal.Traverser = 0
-			oopBestResponse := treeRoot.OverallBestResponse(traversal, oopRelativeProb)
-			traversal.Traverser = 1
-			ipBestResponse := treeRoot.OverallBestResponse(traversal, ipRelativeProb)
+			oopBestResponse, ipBestResponse, exploitability := Exploitability(traversal, treeRoot)
 			fmt.Printf("Iteration %v oop BR: %v ip BR: %v exploitability = ", i, oopBestResponse, ipBestResponse)
-			fmt.Printf("%v percent of the pot\n", (oopBestResponse + ipBestResponse) / 2 / treeRoot.potSize * 100)
+			fmt.Printf("%v percent of the pot after %.2f seconds\n", exploitability, training.Seconds())
+			if traversal.pruning != nil {
+				actions, pruned := traversal.PruningStats()
+				fmt.Printf("Pruned %.1f percent of the hand actions since the last report\n",
+					float64(pruned-reportedPruned)/math.Max(1, float64(actions-reportedActions))*100)
+				reportedActions, reportedPruned = actions, pruned
+			}
 		}
 	}
-}
\ No newline at end of file
+}
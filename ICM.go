@@ -0,0 +1,179 @@
+package solv
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+//maxICMPlayers bounds the players of an ICM, the equities take time exponential in the number of players
+const maxICMPlayers = 16
+
+//ICM is the independent chip model of a tournament, it maps the stacks of the players left to their equity in the
+//prize pool with the Malmuth-Harville model: a player finishes first with its share of the chips as probability,
+//and the places below are decided the same way among the others. Stacks holds the chips of every player at the
+//start of the hand, where the two players of the tree each put half of the starting pot in, Payouts the prize of
+//each place with the first place first and Players the index in Stacks of the oop and the ip player
+type ICM struct {
+	Stacks  []float64
+	Payouts []float64
+	Players [2]int
+}
+
+//Validate returns an error if the stacks, payouts or players of icm do not describe a tournament
+func (icm *ICM) Validate() error {
+	if len(icm.Stacks) < 2 || len(icm.Stacks) > maxICMPlayers {
+		return fmt.Errorf("icm has %v players, it needs between 2 and %v", len(icm.Stacks), maxICMPlayers)
+	}
+	for player, stack := range icm.Stacks {
+		if stack < 0 {
+			return fmt.Errorf("player %v has a negative stack %v", player, stack)
+		}
+	}
+	for place, payout := range icm.Payouts {
+		if payout < 0 {
+			return fmt.Errorf("place %v has a negative payout %v", place+1, payout)
+		}
+	}
+	for _, player := range icm.Players {
+		if player < 0 || player >= len(icm.Stacks) {
+			return fmt.Errorf("player %v of the hand is not one of the %v players", player, len(icm.Stacks))
+		}
+	}
+	if icm.Players[0] == icm.Players[1] {
+		return fmt.Errorf("player %v cannot be both players of the hand", icm.Players[0])
+	}
+	return nil
+}
+
+//Equities returns the tournament equity of every player when the players have stacks. Players without chips
+//finish below every player with chips and split the payouts of their places
+func (icm *ICM) Equities(stacks []float64) []float64 {
+	//the equities of the players left in a set only depend on the set, since it fixes the places still to decide
+	memo := make(map[uint32][]float64)
+	var finish func(left uint32, place int) []float64
+	finish = func(left uint32, place int) []float64 {
+		if cached, ok := memo[left]; ok {
+			return cached
+		}
+		equities := make([]float64, len(stacks))
+		if left == 0 || place >= len(icm.Payouts) {
+			return equities
+		}
+		total := 0.0
+		count := 0
+		for player, stack := range stacks {
+			if left&(1<<uint(player)) != 0 {
+				total += stack
+				count++
+			}
+		}
+		if total == 0 {
+			places := icm.Payouts[place:int(math.Min(float64(place+count), float64(len(icm.Payouts))))]
+			share := 0.0
+			for _, payout := range places {
+				share += payout
+			}
+			for player := range stacks {
+				if left&(1<<uint(player)) != 0 {
+					equities[player] = share / float64(count)
+				}
+			}
+		}
+		for player, stack := range stacks {
+			if left&(1<<uint(player)) == 0 || stack == 0 || total == 0 {
+				continue
+			}
+			probability := stack / total
+			equities[player] += probability * icm.Payouts[place]
+			below := finish(left&^(1<<uint(player)), place+1)
+			for other := range below {
+				equities[other] += probability * below[other]
+			}
+		}
+		memo[left] = equities
+		return equities
+	}
+	return finish(1<<uint(len(stacks))-1, 0)
+}
+
+//payoff is the utility of a player for the pot of a node, win when the player wins the pot, lose when it loses it
+//and tie when the pot is split
+type payoff struct {
+	win  float64
+	lose float64
+	tie  float64
+}
+
+//chipPayoff returns the payoff in chips of a pot with the utilities of rakeUtilities
+func chipPayoff(winUtility, rakeUtility float64) payoff {
+	return payoff{win: winUtility + rakeUtility, lose: -winUtility + rakeUtility, tie: rakeUtility}
+}
+
+//nodePayoff returns the payoff of player at a node with the given chip utilities and icm payoffs, nil if the tree
+//is not valued with an ICM. The payoff is in chips if the traversal asks for chips
+func nodePayoff(traversal *Traversal, icm *[2]payoff, player int, winUtility, rakeUtility float64) payoff {
+	if icm != nil && !traversal.chipPayoffs {
+		return icm[player]
+	}
+	return chipPayoff(winUtility, rakeUtility)
+}
+
+//icmPayoffs computes the payoffs of the pots of trees in tournament equity, the payoffs only depend on the pot and
+//its rake so pots of the same size share them
+type icmPayoffs struct {
+	icm   ICM
+	start []float64
+	pots  map[[2]float64]*[2]payoff
+	mutex sync.Mutex
+}
+
+func newICMPayoffs(icm *ICM) *icmPayoffs {
+	return &icmPayoffs{
+		icm:   *icm,
+		start: icm.Equities(icm.Stacks),
+		pots:  make(map[[2]float64]*[2]payoff),
+	}
+}
+
+//pot returns the payoffs of both players of the hand for pot with rake taken from it, measured from their equity
+//at the start of the hand
+func (payoffs *icmPayoffs) pot(pot, rake float64) *[2]payoff {
+	payoffs.mutex.Lock()
+	defer payoffs.mutex.Unlock()
+	key := [2]float64{pot, rake}
+	if cached, ok := payoffs.pots[key]; ok {
+		return cached
+	}
+	oop, ip := payoffs.icm.Players[0], payoffs.icm.Players[1]
+	equities := func(oopChips, ipChips float64) []float64 {
+		stacks := append([]float64(nil), payoffs.icm.Stacks...)
+		stacks[oop] = math.Max(0, stacks[oop]+oopChips)
+		stacks[ip] = math.Max(0, stacks[ip]+ipChips)
+		return payoffs.icm.Equities(stacks)
+	}
+	oopWins := equities(pot/2-rake, -pot/2)
+	ipWins := equities(-pot/2, pot/2-rake)
+	tie := equities(-rake/2, -rake/2)
+	result := &[2]payoff{
+		{win: oopWins[oop] - payoffs.start[oop], lose: ipWins[oop] - payoffs.start[oop],
+			tie: tie[oop] - payoffs.start[oop]},
+		{win: ipWins[ip] - payoffs.start[ip], lose: oopWins[ip] - payoffs.start[ip], tie: tie[ip] - payoffs.start[ip]},
+	}
+	payoffs.pots[key] = result
+	return result
+}
+
+//TournamentEVs returns the ev of both players playing the average strategies of tree, in chips and in tournament
+//equity, normalized like OverallBestResponse. Trees without an ICM have the same evs in both
+func TournamentEVs(traversal *Traversal, root *GameNode) (chips, equity [2]float64) {
+	for player := range chips {
+		equity[player] = strategyValue(traversal, root, player)
+	}
+	traversal.chipPayoffs = true
+	for player := range chips {
+		chips[player] = strategyValue(traversal, root, player)
+	}
+	traversal.chipPayoffs = false
+	return chips, equity
+}
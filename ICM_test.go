@@ -0,0 +1,115 @@
+package solv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestICMEquities(t *testing.T) {
+	icm := &ICM{Stacks: []float64{10, 30}, Payouts: []float64{100}, Players: [2]int{0, 1}}
+	assert.InDeltaSlice(t, []float64{25, 75}, icm.Equities(icm.Stacks), 1e-9)
+
+	icm = &ICM{Stacks: []float64{50, 30, 20}, Payouts: []float64{50, 30, 20}, Players: [2]int{0, 1}}
+	equities := icm.Equities(icm.Stacks)
+	//the first player finishes second after the second or the third player finishes first
+	second := 0.3*50/70 + 0.2*50/80
+	assert.InDelta(t, 0.5*50+second*30+(1-0.5-second)*20, equities[0], 1e-9)
+	assert.InDelta(t, 100, equities[0]+equities[1]+equities[2], 1e-9)
+	//a player without chips finishes last
+	assert.InDeltaSlice(t, []float64{20, 40, 40}, icm.Equities([]float64{0, 10, 10}), 1e-9)
+	assert.InDeltaSlice(t, []float64{25, 25, 50}, icm.Equities([]float64{0, 0, 10}), 1e-9)
+	//places without payouts are worth nothing
+	icm.Payouts = []float64{100}
+	assert.InDeltaSlice(t, []float64{50, 30, 20}, icm.Equities(icm.Stacks), 1e-9)
+}
+
+func TestICMValidate(t *testing.T) {
+	assert.Nil(t, (&ICM{Stacks: []float64{10, 30, 5}, Payouts: []float64{70, 30}, Players: [2]int{2, 0}}).Validate())
+	assert.NotNil(t, (&ICM{Stacks: []float64{10}, Payouts: []float64{100}}).Validate())
+	assert.NotNil(t, (&ICM{Stacks: []float64{10, 30}, Payouts: []float64{100}, Players: [2]int{1, 1}}).Validate())
+	assert.NotNil(t, (&ICM{Stacks: []float64{10, 30}, Payouts: []float64{100}, Players: [2]int{0, 2}}).Validate())
+	assert.NotNil(t, (&ICM{Stacks: []float64{10, -30}, Payouts: []float64{100}, Players: [2]int{0, 1}}).Validate())
+	assert.NotNil(t, (&ICM{Stacks: []float64{10, 30}, Payouts: []float64{-100}, Players: [2]int{0, 1}}).Validate())
+	assert.NotNil(t, NewConstructionParams(0.75, 1.2).SetICM(&ICM{Stacks: []float64{10, 30}}))
+
+	//leaf estimators value pots in chips, so a tree is either depth limited or valued with an icm
+	icm := &ICM{Stacks: []float64{10, 30}, Payouts: []float64{100}, Players: [2]int{0, 1}}
+	params := NewConstructionParams(0.75, 1.2)
+	assert.Nil(t, params.SetICM(icm))
+	assert.NotNil(t, params.SetDepthLimit(1, EquityEstimator{}))
+	assert.Nil(t, params.SetDepthLimit(1, nil))
+	params = NewConstructionParams(0.75, 1.2)
+	assert.Nil(t, params.SetDepthLimit(1, EquityEstimator{}))
+	assert.NotNil(t, params.SetICM(icm))
+	assert.Nil(t, params.SetICM(nil))
+}
+
+func TestICMPayoffs(t *testing.T) {
+	icm := &ICM{Stacks: []float64{200, 500, 200}, Payouts: []float64{50, 30, 20}, Players: [2]int{2, 0}}
+	pots := newICMPayoffs(icm)
+	payoffs := pots.pot(100, 2)
+	start := icm.Equities(icm.Stacks)
+	assert.InDelta(t, icm.Equities([]float64{150, 500, 248})[2]-start[2], payoffs[0].win, 1e-9)
+	assert.InDelta(t, icm.Equities([]float64{248, 500, 150})[2]-start[2], payoffs[0].lose, 1e-9)
+	assert.InDelta(t, icm.Equities([]float64{199, 500, 199})[2]-start[2], payoffs[0].tie, 1e-9)
+	assert.InDelta(t, icm.Equities([]float64{248, 500, 150})[0]-start[0], payoffs[1].win, 1e-9)
+	//chips won are worth less than chips lost
+	assert.Less(t, payoffs[0].win, -payoffs[0].lose)
+	assert.Same(t, payoffs, pots.pot(100, 2))
+}
+
+func TestLinearICMSolvesLikeChips(t *testing.T) {
+	//with a single winner taking a prize of all the chips, equity is worth the same as chips
+	params := NewConstructionParams(0.75, 1.2)
+	assert.Nil(t, params.SetICM(&ICM{Stacks: []float64{200, 200}, Payouts: []float64{400}, Players: [2]int{0, 1}}))
+	root, traversal := newTurnTestTree(params)
+	//icm trees evaluate every runout of the all ins
+	expectedParams := NewConstructionParams(0.75, 1.2)
+	expectedParams.SetEquityTables(false)
+	expected, expectedTraversal := newTurnTestTree(expectedParams)
+	runIterations(traversal, 50, root)
+	runIterations(expectedTraversal, 50, expected)
+	for hand := 0; hand < root.NumHands(); hand++ {
+		assert.InDeltaSlice(t, expected.Strategy(hand), root.Strategy(hand), 1e-6)
+	}
+	chips, equity := TournamentEVs(traversal, root)
+	assert.InDeltaSlice(t, chips[:], equity[:], 1e-6)
+}
+
+func TestICMTree(t *testing.T) {
+	//the players of the hand share the bubble with a big stack
+	icm := &ICM{Stacks: []float64{200, 200, 600}, Payouts: []float64{50, 30, 20}, Players: [2]int{0, 1}}
+	params := NewConstructionParams(0.75, 1.2)
+	assert.Nil(t, params.SetICM(icm))
+	root, traversal := newTurnTestTree(params)
+	assert.False(t, zeroSum(root))
+	_, _, start := Exploitability(traversal, root)
+	runIterations(traversal, 200, root)
+	_, _, exploitability := Exploitability(traversal, root)
+	assert.Less(t, exploitability, start/10)
+
+	chips, equity := TournamentEVs(traversal, root)
+	assert.InDelta(t, 0.0, chips[0]+chips[1], 1e-9)
+	//every chip the players gamble between them gives equity to the big stack
+	assert.Less(t, equity[0]+equity[1], 0.0)
+	assert.False(t, traversal.chipPayoffs)
+
+	chipRoot, chipTraversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	runIterations(chipTraversal, 200, chipRoot)
+	chipEVs, _ := TournamentEVs(chipTraversal, chipRoot)
+	//the chip solution gambles more, which gives up equity
+	assert.NotEqual(t, chipEVs, chips)
+	assert.Less(t, strategyInvested(root), strategyInvested(chipRoot))
+}
+
+//strategyInvested returns the share of the range the first player bets with at the root
+func strategyInvested(root *GameNode) float64 {
+	strategy := root.GetAverageStrategy()
+	bets := 0.0
+	for hand := range strategy {
+		for action := 1; action < root.NumActions(); action++ {
+			bets += strategy[hand][action]
+		}
+	}
+	return bets / float64(len(strategy))
+}
@@ -0,0 +1,204 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"math"
+)
+
+//suitPermutation maps each suit index (0-3 in the order of suits) to the suit index it is replaced with
+type suitPermutation [4]int
+
+//cardIsomorphism marks a dealt card whose subtree is shared with a canonical card that is the same up to a suit
+//permutation. handPermutations[player][hand] gives the hand of the canonical subtree that plays in place of hand
+type cardIsomorphism struct {
+	canonical        int
+	permutation      suitPermutation
+	handPermutations [2][]int
+}
+
+var allSuitPermutations = generateSuitPermutations()
+
+func generateSuitPermutations() []suitPermutation {
+	permutations := make([]suitPermutation, 0, 24)
+	for a := 0; a < 4; a++ {
+		for b := 0; b < 4; b++ {
+			for c := 0; c < 4; c++ {
+				d := 6 - a - b - c
+				if a == b || a == c || b == c || d == a || d == b || d == c {
+					continue
+				}
+				permutations = append(permutations, suitPermutation{a, b, c, d})
+			}
+		}
+	}
+	return permutations
+}
+
+func permuteCard(card poker.Card, permutation suitPermutation) poker.Card {
+	index := cardTo52Int(card)
+	return intToCard(index - index%4 + permutation[index%4])
+}
+
+func (permutation suitPermutation) inverse() suitPermutation {
+	var inverse suitPermutation
+	for suit, mapped := range permutation {
+		inverse[mapped] = suit
+	}
+	return inverse
+}
+
+//then returns the permutation applying permutation first and next after it
+func (permutation suitPermutation) then(next suitPermutation) suitPermutation {
+	var combined suitPermutation
+	for suit := range permutation {
+		combined[suit] = next[permutation[suit]]
+	}
+	return combined
+}
+
+var identityPermutation = suitPermutation{0, 1, 2, 3}
+
+//handPermutation returns the index in rng of every hand of rng with its suits permuted, or nil if a permuted
+//hand is missing from rng or has a different number of combos
+func handPermutation(rng Range, permutation suitPermutation) []int {
+	indexes := rng.ComboIndexes()
+	mapped := make([]int, len(rng))
+	for index, combo := range rng {
+		first := permuteCard(combo.Hand[0], permutation)
+		second := permuteCard(combo.Hand[1], permutation)
+		mappedIndex := indexes[Hand{first, second}.ComboIndex()]
+		if mappedIndex < 0 || math.Abs(rng[mappedIndex].Combos-combo.Combos) > 1e-9 {
+			return nil
+		}
+		mapped[index] = mappedIndex
+	}
+	return mapped
+}
+
+//boardSymmetries returns the suit permutations other than the identity that leave both ranges and the board of
+//every street unchanged, together with the hand permutations of each range. The first startingCards cards of board
+//are the board the tree starts from, the cards after it were dealt in order. The strategies of earlier streets are
+//only symmetric under the permutations of their own board, so a permutation that moves the board of an earlier
+//street would give isomorphic cards different reach probabilities
+func boardSymmetries(board []poker.Card, startingCards int, ranges [2]Range) ([]suitPermutation, [][2][]int) {
+	permutations := make([]suitPermutation, 0)
+	handPermutations := make([][2][]int, 0)
+	for _, permutation := range allSuitPermutations {
+		if permutation == identityPermutation {
+			continue
+		}
+		preservesBoard := true
+		for street := startingCards; street <= len(board); street++ {
+			for _, card := range board[:street] {
+				preservesBoard = preservesBoard && checkCardBoardOverlap(permuteCard(card, permutation), board[:street])
+			}
+		}
+		if !preservesBoard {
+			continue
+		}
+		oopPermutation := handPermutation(ranges[0], permutation)
+		ipPermutation := handPermutation(ranges[1], permutation)
+		if oopPermutation == nil || ipPermutation == nil {
+			continue
+		}
+		permutations = append(permutations, permutation)
+		handPermutations = append(handPermutations, [2][]int{oopPermutation, ipPermutation})
+	}
+	return permutations, handPermutations
+}
+
+//findCardIsomorphisms returns for each card the isomorphism to the lowest card it can be mapped to by a symmetry
+//of the boards and ranges, or nil if the card is its own canonical card
+func findCardIsomorphisms(cards []poker.Card, board []poker.Card, startingCards int, ranges [2]Range) []*cardIsomorphism {
+	isomorphisms := make([]*cardIsomorphism, len(cards))
+	permutations, handPermutations := boardSymmetries(board, startingCards, ranges)
+	if len(permutations) == 0 {
+		return isomorphisms
+	}
+	var cardIndexes [52]int
+	for card := range cardIndexes {
+		cardIndexes[card] = -1
+	}
+	for index, card := range cards {
+		cardIndexes[cardTo52Int(card)] = index
+	}
+	for index, card := range cards {
+		best := index
+		var bestIsomorphism *cardIsomorphism
+		for i, permutation := range permutations {
+			mappedIndex := cardIndexes[cardTo52Int(permuteCard(card, permutation))]
+			if mappedIndex >= 0 && mappedIndex < best {
+				best = mappedIndex
+				bestIsomorphism = &cardIsomorphism{
+					canonical:        mappedIndex,
+					permutation:      permutation,
+					handPermutations: handPermutations[i],
+				}
+			}
+		}
+		isomorphisms[index] = bestIsomorphism
+	}
+	return isomorphisms
+}
+
+//permuteReach returns reach with every hand moved to the index of the hand played in its place in the canonical
+//subtree, so permuted[handPermutation[hand]] == reach[hand]
+func permuteReach(reach []float64, handPermutation []int) []float64 {
+	return permuteReachInto(make([]float64, len(reach)), reach, handPermutation)
+}
+
+func permuteReachInto(permuted, reach []float64, handPermutation []int) []float64 {
+	for hand := range reach {
+		permuted[handPermutation[hand]] = reach[hand]
+	}
+	return permuted
+}
+
+//unpermuteResultInto maps a result of the canonical subtree back to the hands of the isomorphic card
+func unpermuteResultInto(mapped, result []float64, handPermutation []int) {
+	for hand := range mapped {
+		mapped[hand] = result[handPermutation[hand]]
+	}
+}
+
+func reachEqual(a, b []float64) bool {
+	for hand := range a {
+		if math.Abs(a[hand]-b[hand]) > 1e-12*math.Max(1, math.Abs(a[hand])) {
+			return false
+		}
+	}
+	return true
+}
+
+//cloneSubtree returns a copy of the subtree of node whose game nodes start from the regrets, strategies and locks
+//of the original ones but are updated on their own. Terminal, showdown, all in and leaf nodes hold no training
+//state and are shared with the original
+func cloneSubtree(node Node) Node {
+	switch original := node.(type) {
+	case *GameNode:
+		clone := *original
+		clone.values = original.values.clone()
+		clone.lockFrequencies = append([]float64(nil), original.lockFrequencies...)
+		clone.nextNodes = make([]Node, len(original.nextNodes))
+		for action, next := range original.nextNodes {
+			clone.nextNodes[action] = cloneSubtree(next)
+		}
+		return &clone
+	case *ChanceNode:
+		clone := *original
+		clone.nextNodes = make([]Node, len(original.nextNodes))
+		for index, next := range original.nextNodes {
+			if !original.sharesCanonical(index) {
+				clone.nextNodes[index] = cloneSubtree(next)
+			}
+		}
+		//cards sharing a canonical subtree keep sharing the copy of it
+		for index := range original.nextNodes {
+			if original.sharesCanonical(index) {
+				clone.nextNodes[index] = clone.nextNodes[original.isomorphs[index].canonical]
+			}
+		}
+		return &clone
+	}
+	return node
+}
@@ -0,0 +1,188 @@
+package solv
+
+import (
+	"bytes"
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var monotoneTurn = []poker.Card{poker.NewCard("Ks"), poker.NewCard("7s"), poker.NewCard("5s"), poker.NewCard("3s")}
+
+var isomorphismOOP = RemoveConflicts(HandsStringToHandRange("JJ, AKs, 64s, 98"), monotoneTurn)
+var isomorphismIP = RemoveConflicts(HandsStringToHandRange("QQ, T9, 44, A2s"), monotoneTurn)
+
+func newIsomorphismTestTree(isomorphism bool) (*GameNode, *Traversal) {
+	oop, ip := isomorphismOOP, isomorphismIP
+	params := NewConstructionParams(1.0, 1.2)
+	params.SetIsomorphism(isomorphism)
+	board := make([]poker.Card, len(monotoneTurn))
+	copy(board, monotoneTurn)
+	return mustConstructTree(400, 4000, params, ip, oop, board), NewTraversal(oop, ip)
+}
+
+func TestBoardSymmetries(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Ac"), poker.NewCard("7s"), poker.NewCard("5s")}
+	symmetric := [2]Range{HandsStringToHandRange("JJ, AK"), HandsStringToHandRange("QQ, T9s")}
+	permutations, _ := boardSymmetries(board, len(board), symmetric)
+	//only hearts and diamonds can be swapped
+	assert.Equal(t, []suitPermutation{{0, 2, 1, 3}}, permutations)
+
+	asymmetric := [2]Range{HandsStringToHandRange("JJ, AK"), {*NewCombo(NewHand("Qh", "Qd"), 1), *NewCombo(NewHand("Qh", "Qs"), 1)}}
+	permutations, _ = boardSymmetries(board, len(board), asymmetric)
+	assert.Equal(t, 0, len(permutations))
+
+	cards := Holdem.nextCards(board)
+	isomorphisms := findCardIsomorphisms(cards, board, len(board), symmetric)
+	for index, card := range cards {
+		if card.Suit() == 2 || card.Suit() == 4 {
+			//one of each pair of hearts and diamonds is canonical
+			if isomorphisms[index] != nil {
+				assert.Equal(t, int32(4), card.Suit())
+				assert.Equal(t, permuteCard(card, isomorphisms[index].permutation), cards[isomorphisms[index].canonical])
+			}
+		} else {
+			assert.Nil(t, isomorphisms[index])
+		}
+	}
+}
+
+func TestIsomorphicTreeMatchesFullTree(t *testing.T) {
+	isomorphic, isoTrav := newIsomorphismTestTree(true)
+	full, fullTrav := newIsomorphismTestTree(false)
+
+	chance := isomorphic.GetNext(0).(*GameNode).GetNext(0).(*ChanceNode)
+	canonical := 0
+	for index := range chance.nextCards {
+		if chance.isomorphicCard(index) == nil {
+			canonical++
+		}
+	}
+	//every rank has one spade and one canonical card of the three other suits
+	assert.Equal(t, 9+13, canonical)
+
+	runIterations(isoTrav, 40, isomorphic)
+	runIterations(fullTrav, 40, full)
+	//symmetric reach probabilities never need a subtree of their own
+	assert.Equal(t, 0, countSplitCards(isomorphic))
+	isoOOP, isoIP, _ := Exploitability(isoTrav, isomorphic)
+	fullOOP, fullIP, _ := Exploitability(fullTrav, full)
+	assert.InDelta(t, fullOOP, isoOOP, 0.0001)
+	assert.InDelta(t, fullIP, isoIP, 0.0001)
+	for hand := range isoTrav.Ranges[0] {
+		assert.InDeltaSlice(t, full.GetAverageStrategy()[hand], isomorphic.GetAverageStrategy()[hand], 0.000001)
+	}
+
+	//a line through a card that shares its subtree gives the same spot as in the full tree
+	card := poker.NewCard("2c")
+	betCalled := isomorphic.GetNext(1).(*GameNode).GetNext(0).(*ChanceNode)
+	index := betCalled.CardIndex(card)
+	assert.NotNil(t, betCalled.isomorphicCard(index))
+	isoSpot, err := NewSubgameSpot(isomorphic, isoTrav, monotoneTurn, []int{1, 0, index})
+	assert.Nil(t, err)
+	fullChance := full.GetNext(1).(*GameNode).GetNext(0).(*ChanceNode)
+	fullSpot, err := NewSubgameSpot(full, fullTrav, monotoneTurn, []int{1, 0, fullChance.CardIndex(card)})
+	assert.Nil(t, err)
+	assert.Equal(t, card, isoSpot.Board[4])
+	assert.Equal(t, fullSpot.Board, isoSpot.Board)
+	for player := range fullSpot.Ranges {
+		assert.Equal(t, len(fullSpot.Ranges[player]), len(isoSpot.Ranges[player]))
+		for index := range fullSpot.Ranges[player] {
+			assert.Equal(t, fullSpot.Ranges[player][index].Hand, isoSpot.Ranges[player][index].Hand)
+			assert.InDelta(t, fullSpot.Ranges[player][index].Combos, isoSpot.Ranges[player][index].Combos, 0.000001)
+		}
+	}
+}
+
+func TestIsomorphicChanceNodeWithAsymmetricReach(t *testing.T) {
+	isomorphic, isoTrav := newIsomorphismTestTree(true)
+	full, fullTrav := newIsomorphismTestTree(false)
+	//locking a hand that no suit permutation of the board keeps breaks the symmetry of the reach probabilities at
+	//the chance nodes, so the cards it makes asymmetric need subtrees of their own
+	locked := NewHand("9h", "8d")
+	for _, root := range []*GameNode{isomorphic, full} {
+		strategy := make([][]float64, len(isoTrav.Ranges[0]))
+		for hand := range strategy {
+			strategy[hand] = []float64{1, 1}
+		}
+		strategy[isoTrav.HandIndex(0, locked)] = []float64{1, 0}
+		assert.Nil(t, root.LockStrategy(strategy))
+	}
+	runIterations(isoTrav, 20, isomorphic)
+	runIterations(fullTrav, 20, full)
+	isoOOP, isoIP, _ := Exploitability(isoTrav, isomorphic)
+	fullOOP, fullIP, _ := Exploitability(fullTrav, full)
+	assert.InDelta(t, fullOOP, isoOOP, 0.0001)
+	assert.InDelta(t, fullIP, isoIP, 0.0001)
+	assert.Greater(t, countSplitCards(isomorphic), 0)
+
+	//a saved solution gives the cards of a new tree the same subtrees
+	var buffer bytes.Buffer
+	assert.Nil(t, SaveSolution(&buffer, isomorphic))
+	loaded, loadedTrav := newIsomorphismTestTree(true)
+	assert.Nil(t, LoadSolution(bytes.NewReader(buffer.Bytes()), loaded))
+	assert.Equal(t, countSplitCards(isomorphic), countSplitCards(loaded))
+	loadedOOP, loadedIP, _ := Exploitability(loadedTrav, loaded)
+	assert.InDelta(t, isoOOP, loadedOOP, 1e-9)
+	assert.InDelta(t, isoIP, loadedIP, 1e-9)
+}
+
+//countSplitCards returns the number of isomorphic cards of the tree with a subtree of their own
+func countSplitCards(root *GameNode) int {
+	split := 0
+	walkChanceNodes(root, func(node *ChanceNode) error {
+		split += len(node.splitCards())
+		return nil
+	})
+	return split
+}
+
+func TestEvaluateStrategyOnIsomorphicTree(t *testing.T) {
+	isomorphic, isoTrav := newIsomorphismTestTree(true)
+	full, fullTrav := newIsomorphismTestTree(false)
+	runIterations(isoTrav, 20, isomorphic)
+	runIterations(fullTrav, 20, full)
+
+	isoReport, err := EvaluateStrategy(isoTrav, isomorphic, 1, StrategyOverrides{})
+	assert.Nil(t, err)
+	fullReport, err := EvaluateStrategy(fullTrav, full, 1, StrategyOverrides{})
+	assert.Nil(t, err)
+	assert.InDelta(t, fullReport.EV, isoReport.EV, 0.0001)
+
+	totalLoss := func(report *StrategyReport) (float64, map[Hand]float64) {
+		total := 0.0
+		hands := make(map[Hand]float64)
+		for _, nodeLoss := range report.NodeLosses {
+			total += nodeLoss.Loss
+			for _, handLoss := range nodeLoss.Hands {
+				hands[handLoss.Hand] += handLoss.Loss
+			}
+		}
+		return total, hands
+	}
+	isoTotal, isoHands := totalLoss(isoReport)
+	fullTotal, fullHands := totalLoss(fullReport)
+	assert.InDelta(t, fullTotal, isoTotal, 0.0001)
+	assert.Equal(t, len(fullReport.NodeLosses), len(isoReport.NodeLosses))
+	for hand := range fullHands {
+		assert.InDelta(t, fullHands[hand], isoHands[hand], 0.0001)
+	}
+}
+
+func TestWarmStartBetweenIsomorphicAndFullTrees(t *testing.T) {
+	isomorphic, isoTrav := newIsomorphismTestTree(true)
+	runIterations(isoTrav, 20, isomorphic)
+	isoOOP, isoIP, _ := Exploitability(isoTrav, isomorphic)
+
+	full, fullTrav := newIsomorphismTestTree(false)
+	WarmStart(full, fullTrav, isomorphic, isoTrav)
+	fullOOP, fullIP, _ := Exploitability(fullTrav, full)
+	assert.InDelta(t, isoOOP, fullOOP, 0.0001)
+	assert.InDelta(t, isoIP, fullIP, 0.0001)
+
+	again, againTrav := newIsomorphismTestTree(true)
+	WarmStart(again, againTrav, full, fullTrav)
+	againOOP, againIP, _ := Exploitability(againTrav, again)
+	assert.InDelta(t, isoOOP, againOOP, 0.0001)
+	assert.InDelta(t, isoIP, againIP, 0.0001)
+}
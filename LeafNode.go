@@ -0,0 +1,134 @@
+package solv
+
+import (
+	"fmt"
+	"github.com/chehsunliu/poker"
+)
+
+//LeafEstimator estimates the utility of the hands at the leaves of a depth limited tree, in place of solving the
+//streets after the leaf. Utilities are measured like at a showdown, a hand winning the pot gets half of it and a
+//hand losing it minus half of it, with rake the winner gets half of it minus the Rake of the leaf
+type LeafEstimator interface {
+	//Estimate adds the utility of every hand of player at leaf to utility, against the opponent hands with the
+	//given reach probabilities. utility is zeroed and has one entry per hand of the range of player
+	Estimate(traversal *Traversal, leaf *LeafNode, player int, opponentReachProb, utility []float64)
+}
+
+//LeafEstimatorFunc lets an ordinary function estimate the utilities of leaves
+type LeafEstimatorFunc func(traversal *Traversal, leaf *LeafNode, player int, opponentReachProb, utility []float64)
+
+func (estimate LeafEstimatorFunc) Estimate(traversal *Traversal, leaf *LeafNode, player int, opponentReachProb,
+	utility []float64) {
+	estimate(traversal, leaf, player, opponentReachProb, utility)
+}
+
+//EquityEstimator values the hands at a leaf by their equity against the opponent hands, as if the hands were
+//checked down to the river
+type EquityEstimator struct{}
+
+func (EquityEstimator) Estimate(traversal *Traversal, leaf *LeafNode, player int, opponentReachProb,
+	utility []float64) {
+	leaf.EquityTable().utilityInto(utility, player, opponentReachProb, leaf.winUtility)
+	if leaf.rakeUtility != 0 {
+		opponentReachUtility(traversal, player, leaf.board, utility, opponentReachProb, leaf.rakeUtility)
+	}
+}
+
+//RealizationEstimator values the hands at a leaf by their equity times the realization factor of their player, so
+//a factor above one lets the player win more of the pot than its equity, as a player in position usually does.
+//The utilities of the players do not sum to zero unless both factors are one
+type RealizationEstimator struct {
+	Realization [2]float64
+}
+
+func (estimator RealizationEstimator) Estimate(traversal *Traversal, leaf *LeafNode, player int, opponentReachProb,
+	utility []float64) {
+	leaf.EquityTable().utilityInto(utility, player, opponentReachProb, leaf.winUtility)
+	//the share of the pot after rake is the equity utility plus half of it, scaled by the realization
+	realization := estimator.Realization[player]
+	for hand := range utility {
+		utility[hand] *= realization
+	}
+	opponentReachUtility(traversal, player, leaf.board, utility, opponentReachProb,
+		(realization-1)*leaf.winUtility+leaf.rakeUtility)
+}
+
+//LeafNode ends a depth limited tree where a street is over and the next card would be dealt, its utility is
+//given by the LeafEstimator of the tree
+type LeafNode struct {
+	potSize float64
+	winUtility float64
+	rakeUtility float64
+	stacks float64
+	board []poker.Card
+	cache *RiverEvaluationCache
+	estimator LeafEstimator
+}
+
+func NewLeafNode(potSize, stacks float64, board []poker.Card, cache *RiverEvaluationCache,
+	estimator LeafEstimator) *LeafNode {
+	return &LeafNode{
+		potSize: potSize,
+		winUtility: potSize / 2.0,
+		stacks: stacks,
+		board: board,
+		cache: cache,
+		estimator: estimator,
+	}
+}
+
+//setRake takes rake from the pot of the leaf
+func (node *LeafNode) setRake(rake float64) {
+	node.winUtility, node.rakeUtility = rakeUtilities(node.potSize, rake)
+}
+
+//PotSize returns the pot at the leaf
+func (node *LeafNode) PotSize() float64 {
+	return node.potSize
+}
+
+//Rake returns the rake taken from the pot of the leaf when it is won
+func (node *LeafNode) Rake() float64 {
+	return -2 * node.rakeUtility
+}
+
+//EffectiveStack returns the stack both players have left behind at the leaf
+func (node *LeafNode) EffectiveStack() float64 {
+	return node.stacks
+}
+
+//Board returns the cards dealt before the leaf, it must not be modified
+func (node *LeafNode) Board() []poker.Card {
+	return node.board
+}
+
+//EquityTable returns the all in equity table of the board of the leaf for the ranges of the tree
+func (node *LeafNode) EquityTable() *EquityTable {
+	return node.cache.EquityTable(node.board)
+}
+
+func (node *LeafNode) CFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
+	utility := traversal.level().utility(traversal.utilitySize())
+	if traversal.simultaneous {
+		reach := [2][]float64{traverserReachProb, opponentReachProb}
+		for player := range reach {
+			node.estimator.Estimate(traversal, node, player, reach[player^1], traversal.playerUtility(utility, player))
+		}
+		return utility
+	}
+	node.estimator.Estimate(traversal, node, traversal.Traverser, opponentReachProb, utility)
+	return utility
+}
+
+func (node *LeafNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
+	utility := make([]float64, len(traversal.Ranges[traversal.Traverser]))
+	node.estimator.Estimate(traversal, node, traversal.Traverser, opponentReachProb, utility)
+	return utility
+}
+
+func (node *LeafNode) PrintNodeDetails(level int) {
+	for i := 0; i < level; i++ {
+		fmt.Print("\t")
+	}
+	fmt.Printf("LeafNode pot %v stacks %v\n", node.potSize, node.stacks)
+}
@@ -0,0 +1,100 @@
+package solv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func newLeafTestNode(oopHands, ipHands string, estimator LeafEstimator) (*LeafNode, *AllInShowdownNode, *Traversal) {
+	table, _, traversal := newAllInTestNodes(equityTurn, oopHands, ipHands)
+	return NewLeafNode(100, 50, equityTurn, table.cache, estimator), table, traversal
+}
+
+func TestEquityEstimatorChecksDown(t *testing.T) {
+	leaf, table, traversal := newLeafTestNode("TT+, AQs+, KQs, AK, 98s", "99+, ATs+, KTs+, QJs, AQ+, 43s",
+		EquityEstimator{})
+	random := rand.New(rand.NewSource(3))
+	for traverser := range traversal.Ranges {
+		traversal.Traverser = traverser
+		opponentReach := randomReach(len(traversal.Ranges[traverser^1]), random)
+		expected := append([]float64(nil), table.CFRTraversal(traversal, nil, opponentReach)...)
+		assert.Equal(t, expected, leaf.CFRTraversal(traversal, nil, opponentReach))
+		assert.Equal(t, expected, leaf.BestResponse(traversal, opponentReach))
+	}
+}
+
+func TestRealizationEstimator(t *testing.T) {
+	realization := [2]float64{0.8, 1.1}
+	leaf, _, traversal := newLeafTestNode("TT+, AQs+, KQs", "99+, ATs+, QJs, 43s",
+		RealizationEstimator{Realization: realization})
+	random := rand.New(rand.NewSource(5))
+	for traverser := range traversal.Ranges {
+		traversal.Traverser = traverser
+		opponent := traverser ^ 1
+		opponentReach := randomReach(len(traversal.Ranges[opponent]), random)
+		utility := leaf.CFRTraversal(traversal, nil, opponentReach)
+		for hand, combo := range traversal.Ranges[traverser] {
+			expected := 0.0
+			for opponentHand, opponentCombo := range traversal.Ranges[opponent] {
+				if CheckHandOverlap(combo.Hand, opponentCombo.Hand) {
+					continue
+				}
+				var outcome float64
+				if traverser == 0 {
+					outcome = leaf.EquityTable().outcome(hand, opponentHand)
+				} else {
+					outcome = -leaf.EquityTable().outcome(opponentHand, hand)
+				}
+				equity := (outcome + 1) / 2
+				expected += opponentReach[opponentHand] * (realization[traverser]*equity*leaf.potSize - leaf.winUtility)
+			}
+			assert.InDelta(t, expected, utility[hand], 1e-9)
+		}
+	}
+}
+
+func TestDepthLimitedTree(t *testing.T) {
+	params := NewConstructionParams(0.75, 1.2)
+	assert.Nil(t, params.SetDepthLimit(1, EquityEstimator{}))
+	root, traversal := newFlopTestTree(params)
+	//check, check ends the flop
+	leaf, err := NodeAtPath(root, []int{0, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, 100.0, leaf.(*LeafNode).PotSize())
+	assert.Equal(t, 150.0, leaf.(*LeafNode).EffectiveStack())
+	assert.Len(t, leaf.(*LeafNode).Board(), 3)
+	walkGameNodes(root, func(node *GameNode) {
+		for _, next := range node.nextNodes {
+			_, isChance := next.(*ChanceNode)
+			assert.False(t, isChance)
+		}
+	})
+
+	_, _, start := Exploitability(traversal, root)
+	runIterations(traversal, 300, root)
+	_, _, exploitability := Exploitability(traversal, root)
+	assert.Less(t, exploitability, start/50)
+
+	//a function estimating the same utilities solves to the same strategies
+	calls := 0
+	estimate := LeafEstimatorFunc(func(traversal *Traversal, leaf *LeafNode, player int, opponentReachProb,
+		utility []float64) {
+		calls++
+		EquityEstimator{}.Estimate(traversal, leaf, player, opponentReachProb, utility)
+	})
+	assert.Nil(t, params.SetDepthLimit(1, estimate))
+	pool := NewWorkerPool(1)
+	defer pool.Close()
+	params.SetWorkerPool(pool)
+	same, sameTraversal := newFlopTestTree(params)
+	sameTraversal.SetWorkerPool(pool)
+	runIterations(sameTraversal, 300, same)
+	assert.Greater(t, calls, 0)
+	assertSameValues(t, root, same)
+
+	//the depth limited solve warm starts the full tree
+	full, fullTraversal := newFlopTestTree(NewConstructionParams(0.75, 1.2))
+	WarmStart(full, fullTraversal, root, traversal)
+	assert.Equal(t, root.Regrets(0), full.Regrets(0))
+}
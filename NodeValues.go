@@ -0,0 +1,328 @@
+package solv
+
+import "sync"
+
+//nodeValues stores the regrets, current strategies and strategy sums of every hand and action of a GameNode in
+//one flat allocation, the value of a hand and action is at index hand*numActions+action. Single precision nodes
+//keep the values as float32 to halve the memory of large trees, only one of the two sets of slices is used
+type nodeValues struct {
+	numActions int
+
+	regrets      []float64
+	strategies   []float64
+	strategySums []float64
+
+	regrets32      []float32
+	strategies32   []float32
+	strategySums32 []float32
+}
+
+func newNodeValues(numHands, numActions int, singlePrecision bool) nodeValues {
+	size := numHands * numActions
+	values := nodeValues{numActions: numActions}
+	if singlePrecision {
+		data := make([]float32, 3*size)
+		values.regrets32 = data[:size:size]
+		values.strategies32 = data[size : 2*size : 2*size]
+		values.strategySums32 = data[2*size:]
+	} else {
+		data := make([]float64, 3*size)
+		values.regrets = data[:size:size]
+		values.strategies = data[size : 2*size : 2*size]
+		values.strategySums = data[2*size:]
+	}
+	return values
+}
+
+//clone returns a copy of the values that shares no memory with them
+func (values *nodeValues) clone() nodeValues {
+	clone := newNodeValues(values.numHands(), values.numActions, values.singlePrecision())
+	copy(clone.regrets, values.regrets)
+	copy(clone.strategies, values.strategies)
+	copy(clone.strategySums, values.strategySums)
+	copy(clone.regrets32, values.regrets32)
+	copy(clone.strategies32, values.strategies32)
+	copy(clone.strategySums32, values.strategySums32)
+	return clone
+}
+
+func (values *nodeValues) singlePrecision() bool {
+	return values.regrets32 != nil
+}
+
+func (values *nodeValues) numHands() int {
+	if values.numActions == 0 {
+		return 0
+	}
+	if values.singlePrecision() {
+		return len(values.regrets32) / values.numActions
+	}
+	return len(values.regrets) / values.numActions
+}
+
+func (values *nodeValues) regret(hand, action int) float64 {
+	if values.singlePrecision() {
+		return float64(values.regrets32[hand*values.numActions+action])
+	}
+	return values.regrets[hand*values.numActions+action]
+}
+
+func (values *nodeValues) setRegret(hand, action int, regret float64) {
+	if values.singlePrecision() {
+		values.regrets32[hand*values.numActions+action] = float32(regret)
+	} else {
+		values.regrets[hand*values.numActions+action] = regret
+	}
+}
+
+func (values *nodeValues) strategy(hand, action int) float64 {
+	if values.singlePrecision() {
+		return float64(values.strategies32[hand*values.numActions+action])
+	}
+	return values.strategies[hand*values.numActions+action]
+}
+
+func (values *nodeValues) setStrategy(hand, action int, strategy float64) {
+	if values.singlePrecision() {
+		values.strategies32[hand*values.numActions+action] = float32(strategy)
+	} else {
+		values.strategies[hand*values.numActions+action] = strategy
+	}
+}
+
+func (values *nodeValues) strategySum(hand, action int) float64 {
+	if values.singlePrecision() {
+		return float64(values.strategySums32[hand*values.numActions+action])
+	}
+	return values.strategySums[hand*values.numActions+action]
+}
+
+func (values *nodeValues) setStrategySum(hand, action int, sum float64) {
+	if values.singlePrecision() {
+		values.strategySums32[hand*values.numActions+action] = float32(sum)
+	} else {
+		values.strategySums[hand*values.numActions+action] = sum
+	}
+}
+
+//handValues returns the values of one hand, a view into the storage in double precision and a copy otherwise
+func (values *nodeValues) handValues(data []float64, data32 []float32, hand int) []float64 {
+	start := hand * values.numActions
+	if !values.singlePrecision() {
+		return data[start : start+values.numActions : start+values.numActions]
+	}
+	converted := make([]float64, values.numActions)
+	for action := range converted {
+		converted[action] = float64(data32[start+action])
+	}
+	return converted
+}
+
+//regretMatch sets the strategy of a hand proportional to its positive regrets, or uniform if there are none
+func (values *nodeValues) regretMatch(hand int) {
+	start := hand * values.numActions
+	end := start + values.numActions
+	if values.singlePrecision() {
+		normalizingSum := float32(0)
+		for i := start; i < end; i++ {
+			values.strategies32[i] = 0
+			if values.regrets32[i] > 0 {
+				values.strategies32[i] = values.regrets32[i]
+			}
+			normalizingSum += values.strategies32[i]
+		}
+		for i := start; i < end; i++ {
+			if normalizingSum > 0 {
+				values.strategies32[i] /= normalizingSum
+			} else {
+				values.strategies32[i] = 1 / float32(values.numActions)
+			}
+		}
+		return
+	}
+	normalizingSum := 0.0
+	for i := start; i < end; i++ {
+		values.strategies[i] = 0
+		if values.regrets[i] > 0 {
+			values.strategies[i] = values.regrets[i]
+		}
+		normalizingSum += values.strategies[i]
+	}
+	for i := start; i < end; i++ {
+		if normalizingSum > 0 {
+			values.strategies[i] /= normalizingSum
+		} else {
+			values.strategies[i] = 1.0 / float64(values.numActions)
+		}
+	}
+}
+
+//update adds the reach weighted strategy to the strategy sums and the regret of each action to the regrets of
+//the given hands, then applies the discounting. The regrets of actions pruned below pruneLimit are left as they are
+func (values *nodeValues) update(hands []int, reachProbability, nodeUtility []float64, actionUtility [][]float64,
+	positiveRegret, negativeRegret, strategyWeight, pruneLimit float64) {
+	if values.singlePrecision() {
+		for _, hand := range hands {
+			start := hand * values.numActions
+			for action := 0; action < values.numActions; action++ {
+				i := start + action
+				sum := float64(values.strategySums32[i]) + reachProbability[hand]*float64(values.strategies32[i])
+				values.strategySums32[i] = float32(sum * strategyWeight)
+				if values.strategies32[i] == 0 && float64(values.regrets32[i]) < pruneLimit {
+					continue
+				}
+				regret := float64(values.regrets32[i]) + actionUtility[action][hand] - nodeUtility[hand]
+				if regret > 0 {
+					regret *= positiveRegret
+				} else {
+					regret *= negativeRegret
+				}
+				values.regrets32[i] = float32(regret)
+			}
+		}
+		return
+	}
+	for _, hand := range hands {
+		start := hand * values.numActions
+		for action := 0; action < values.numActions; action++ {
+			i := start + action
+			values.strategySums[i] += reachProbability[hand] * values.strategies[i]
+			values.strategySums[i] *= strategyWeight
+			//the utility of a pruned action was not computed
+			if values.strategies[i] == 0 && values.regrets[i] < pruneLimit {
+				continue
+			}
+			values.regrets[i] += actionUtility[action][hand] - nodeUtility[hand]
+			if values.regrets[i] > 0 {
+				values.regrets[i] *= positiveRegret
+			} else {
+				values.regrets[i] *= negativeRegret
+			}
+		}
+	}
+}
+
+//bucketUpdates collects the updates of the game nodes whose values are shared by the cards of a bucket while
+//the cards are traversed one after another, so the values get a single update and discount per iteration like
+//the values of a card of its own. The regrets are left as they are until then, so every card regret matches to
+//the same strategy
+type bucketUpdates struct {
+	updates map[interface{}]*valuesUpdate
+	mutex   sync.Mutex
+}
+
+//valuesUpdate holds the summed regrets and reach weighted strategies of one set of values, regretUpdated and
+//handUpdated mark the entries an immediate update would have changed
+type valuesUpdate struct {
+	values        nodeValues
+	regrets       []float64
+	strategySums  []float64
+	regretUpdated []bool
+	handUpdated   []bool
+}
+
+func newBucketUpdates() *bucketUpdates {
+	return &bucketUpdates{updates: make(map[interface{}]*valuesUpdate)}
+}
+
+//valuesUpdate returns the update of values, creating it the first time values are updated. The nodes sharing
+//values share their regrets, so the first regret tells the values apart
+func (bucket *bucketUpdates) valuesUpdate(values nodeValues) *valuesUpdate {
+	var key interface{} = &values.regrets[0]
+	if values.singlePrecision() {
+		key = &values.regrets32[0]
+	}
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+	update, ok := bucket.updates[key]
+	if !ok {
+		size := len(values.regrets) + len(values.regrets32)
+		update = &valuesUpdate{
+			values:        values,
+			regrets:       make([]float64, size),
+			strategySums:  make([]float64, size),
+			regretUpdated: make([]bool, size),
+			handUpdated:   make([]bool, values.numHands()),
+		}
+		bucket.updates[key] = update
+	}
+	return update
+}
+
+//add sums the update of the given hands of values like update would apply it, without discounting. Each node is
+//only traversed by one goroutine at a time, so only the map needs the mutex
+func (bucket *bucketUpdates) add(values nodeValues, hands []int, reachProbability, nodeUtility []float64,
+	actionUtility [][]float64, pruneLimit float64) {
+	update := bucket.valuesUpdate(values)
+	for _, hand := range hands {
+		update.handUpdated[hand] = true
+		for action := 0; action < values.numActions; action++ {
+			i := hand*values.numActions + action
+			update.strategySums[i] += reachProbability[hand] * values.strategy(hand, action)
+			if values.pruned(hand, action, pruneLimit) {
+				continue
+			}
+			update.regrets[i] += actionUtility[action][hand] - nodeUtility[hand]
+			update.regretUpdated[i] = true
+		}
+	}
+}
+
+//apply adds the summed updates to their values and discounts them once
+func (bucket *bucketUpdates) apply(positiveRegret, negativeRegret, strategyWeight float64) {
+	for _, update := range bucket.updates {
+		values := update.values
+		for hand, updated := range update.handUpdated {
+			if !updated {
+				continue
+			}
+			for action := 0; action < values.numActions; action++ {
+				i := hand*values.numActions + action
+				values.setStrategySum(hand, action, (values.strategySum(hand, action)+update.strategySums[i])*strategyWeight)
+				if !update.regretUpdated[i] {
+					continue
+				}
+				regret := values.regret(hand, action) + update.regrets[i]
+				if regret > 0 {
+					regret *= positiveRegret
+				} else {
+					regret *= negativeRegret
+				}
+				values.setRegret(hand, action, regret)
+			}
+		}
+	}
+}
+
+//actionReach writes the reach probabilities after each of the given hands takes action with its current strategy
+//to next, the other hands get no reach
+func (values *nodeValues) actionReach(hands []int, next, reach []float64, action int) {
+	if len(hands) < len(reach) {
+		for hand := range next {
+			next[hand] = 0
+		}
+	}
+	if values.singlePrecision() {
+		for _, hand := range hands {
+			next[hand] = float64(values.strategies32[hand*values.numActions+action]) * reach[hand]
+		}
+		return
+	}
+	for _, hand := range hands {
+		next[hand] = values.strategies[hand*values.numActions+action] * reach[hand]
+	}
+}
+
+//addWeightedUtility adds the utility of action weighted by the current strategy of each of the given hands to
+//nodeUtility
+func (values *nodeValues) addWeightedUtility(hands []int, nodeUtility, actionUtility []float64, action int) {
+	if values.singlePrecision() {
+		for _, hand := range hands {
+			nodeUtility[hand] += float64(values.strategies32[hand*values.numActions+action]) * actionUtility[hand]
+		}
+		return
+	}
+	for _, hand := range hands {
+		nodeUtility[hand] += values.strategies[hand*values.numActions+action] * actionUtility[hand]
+	}
+}
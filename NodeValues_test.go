@@ -0,0 +1,75 @@
+package solv
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSinglePrecisionMatchesDoublePrecision(t *testing.T) {
+	params := NewConstructionParams(1.0, 1.2)
+	double, doubleTrav := newRiverTestTree(params)
+	params.SetSinglePrecision(true)
+	single, singleTrav := newRiverTestTree(params)
+	assert.False(t, double.values.singlePrecision())
+	assert.True(t, single.values.singlePrecision())
+
+	runIterations(doubleTrav, 50, double)
+	runIterations(singleTrav, 50, single)
+	_, _, doubleExploitability := Exploitability(doubleTrav, double)
+	_, _, singleExploitability := Exploitability(singleTrav, single)
+	assert.InDelta(t, doubleExploitability, singleExploitability, 0.01)
+	for hand := 0; hand < double.NumHands(); hand++ {
+		assert.InDeltaSlice(t, double.GetAverageStrategy()[hand], single.GetAverageStrategy()[hand], 0.01)
+	}
+
+	//solutions keep one slice per hand in double precision, so they load into either kind of tree
+	var buffer bytes.Buffer
+	assert.Nil(t, SaveSolution(&buffer, double))
+	loaded, _ := newRiverTestTree(params)
+	assert.Nil(t, LoadSolution(bytes.NewReader(buffer.Bytes()), loaded))
+	for hand := 0; hand < double.NumHands(); hand++ {
+		assert.InDeltaSlice(t, double.StrategySums(hand), loaded.StrategySums(hand), 1e-3)
+	}
+}
+
+func TestGameNodeAccessors(t *testing.T) {
+	for _, singlePrecision := range []bool{false, true} {
+		node := NewGameNode(0, 10, 100, 100)
+		node.numActions = 3
+		node.initializeHandValues(4, singlePrecision)
+		assert.Equal(t, 4, node.NumHands())
+		node.values.setRegret(2, 1, 1.5)
+		node.values.setStrategySum(3, 2, 0.25)
+		node.values.regretMatch(2)
+		assert.Equal(t, []float64{0, 1.5, 0}, node.Regrets(2))
+		assert.Equal(t, []float64{0, 0, 0.25}, node.StrategySums(3))
+		assert.Equal(t, []float64{0, 1, 0}, node.Strategy(2))
+		assert.Equal(t, []float64{0, 0, 1}, node.GetAverageStrategy()[3])
+
+		//double precision accessors are views into the node's storage, single precision ones are copies
+		node.Regrets(0)[0] = 1
+		if singlePrecision {
+			assert.Equal(t, 0.0, node.values.regret(0, 0))
+		} else {
+			assert.Equal(t, 1.0, node.values.regret(0, 0))
+		}
+	}
+}
+
+func benchmarkConstructTurnTree(b *testing.B, singlePrecision bool) {
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetSinglePrecision(singlePrecision)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		newTurnTestTree(params)
+	}
+}
+
+func BenchmarkConstructTurnTreeDoublePrecision(b *testing.B) {
+	benchmarkConstructTurnTree(b, false)
+}
+
+func BenchmarkConstructTurnTreeSinglePrecision(b *testing.B) {
+	benchmarkConstructTurnTree(b, true)
+}
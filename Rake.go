@@ -0,0 +1,28 @@
+package solv
+
+import "math"
+
+//Rake is what the house takes from a pot before it goes to the winner, Percent percent of the pot up to Cap chips,
+//or without a cap if Cap is 0. The zero Rake takes nothing. There is no "no flop, no drop" option: it only spares
+//the pots of hands that end before the flop, and the trees start on the flop or later, so every pot of a tree has
+//seen a flop and is raked
+type Rake struct {
+	Percent float64
+	Cap     float64
+}
+
+//Amount returns the rake taken from pot
+func (rake Rake) Amount(pot float64) float64 {
+	amount := pot * rake.Percent / 100
+	if rake.Cap > 0 {
+		amount = math.Min(amount, rake.Cap)
+	}
+	return amount
+}
+
+//rakeUtilities splits the payoffs of a raked pot into a part where the winner gets winUtility and the loser loses
+//it, like the payoffs without rake, and rakeUtility which both players get whoever wins. The winner gets the pot
+//minus the rake, the loser loses half the pot and a tie splits the pot minus the rake
+func rakeUtilities(pot, rake float64) (winUtility, rakeUtility float64) {
+	return (pot - rake) / 2.0, -rake / 2.0
+}
@@ -0,0 +1,126 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestRakeAmount(t *testing.T) {
+	rake := Rake{Percent: 5, Cap: 3}
+	assert.InDelta(t, 2.5, rake.Amount(50), 1e-9)
+	assert.Equal(t, 3.0, rake.Amount(100))
+	assert.Equal(t, 10.0, Rake{Percent: 5}.Amount(200))
+	assert.Equal(t, 0.0, Rake{}.Amount(200))
+}
+
+func TestRakedFoldPaysTheWinnerLess(t *testing.T) {
+	fold := NewTerminalNode(NewGameNode(0, 20, 15, 15))
+	fold.setRake(2)
+	oopRange := HandsStringToHandRange("KQs, /50.0/QJs, KK+, /25.0/55, 87s")
+	ipRange := HandsStringToHandRange("KQs, QJs, AA, 55, 87s")
+	traversal := NewTraversal(oopRange, ipRange)
+	oopReach := convertRangeToFloatSlice(oopRange)
+	ipReach := convertRangeToFloatSlice(ipRange)
+	//oop wins the pot minus the rake, ip loses its half of the pot
+	assert.InDeltaSlice(t, fold.TraverserUtilSlow(oopRange, ipRange, 8), fold.GetUtil(traversal, oopReach, ipReach), 1e-9)
+	traversal.Traverser = 1
+	assert.InDeltaSlice(t, fold.TraverserUtilSlow(ipRange, oopRange, -10), fold.GetUtil(traversal, ipReach, oopReach),
+		1e-9)
+}
+
+func TestRakedShowdown(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c"),
+		poker.NewCard("Ts")}
+	oop := RemoveConflicts(HandsStringToHandRange("AA, KQ, 99, 87s, 65s, /50.0/JTs"), board)
+	ip := RemoveConflicts(HandsStringToHandRange("KK, AK, T9s, 44, 32s, /25.0/JJ, JTs"), board)
+	cache := NewRiverEvaluationCache(oop, ip)
+	node := NewShowdownNode(100, 1, board, cache)
+	node.cacheIndex = cache.InsertBoard(board)
+	node.setRake(4)
+	traversal := NewTraversal(oop, ip)
+	random := rand.New(rand.NewSource(2))
+	for traverser := range traversal.Ranges {
+		traversal.Traverser = traverser
+		opponentReach := randomReach(len(traversal.Ranges[traverser^1]), random)
+		ranks := cache.RankingCache[node.cacheIndex]
+		expected := make([]float64, len(traversal.Ranges[traverser]))
+		for _, hand := range ranks[traverser] {
+			for _, opponentHand := range ranks[traverser^1] {
+				if CheckHandOverlap(hand.Hand, opponentHand.Hand) {
+					continue
+				}
+				//the winner gets 50 minus the rake of 4, the loser loses 50 and a tie gets half of the rake taken
+				payoff := -2.0
+				if hand.Rank < opponentHand.Rank {
+					payoff = 46
+				} else if hand.Rank > opponentHand.Rank {
+					payoff = -50
+				}
+				expected[hand.Index] += opponentReach[opponentHand.Index] * payoff
+			}
+		}
+		assert.InDeltaSlice(t, expected, node.GetUtil(traversal, opponentReach), 1e-9)
+	}
+}
+
+func TestRakedEquityTableMatchesRunouts(t *testing.T) {
+	random := rand.New(rand.NewSource(4))
+	for _, board := range [][]poker.Card{equityFlop, equityTurn} {
+		table, runouts, traversal := newAllInTestNodes(board, "AA, KQ, 99, 87s, 65s", "KK, AK, T9s, 44, 32s")
+		table.setRake(3, board)
+		for _, showdown := range runouts.nextNodes {
+			showdown.setRake(3)
+		}
+		for traverser := range traversal.Ranges {
+			traversal.Traverser = traverser
+			traverserReach := randomReach(len(traversal.Ranges[traverser]), random)
+			opponentReach := randomReach(len(traversal.Ranges[traverser^1]), random)
+			expected := append([]float64(nil), runouts.CFRTraversal(traversal, traverserReach, opponentReach)...)
+			assert.InDeltaSlice(t, expected, table.CFRTraversal(traversal, traverserReach, opponentReach), 1e-9)
+			assert.InDeltaSlice(t, runouts.BestResponse(traversal, opponentReach),
+				table.BestResponse(traversal, opponentReach), 1e-9)
+		}
+	}
+}
+
+func TestRakedTree(t *testing.T) {
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetRake(Rake{Percent: 5, Cap: 8})
+	root, traversal := newTurnTestTree(params)
+	assert.False(t, zeroSum(root))
+	//check, check deals the river, where a check back is a showdown of the 100 chip pot
+	showdown, err := NodeAtPath(root, []int{0, 0, 0, 0, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, 47.5, showdown.(*ShowdownNode).winUtility)
+	assert.Equal(t, -2.5, showdown.(*ShowdownNode).rakeUtility)
+
+	_, _, start := Exploitability(traversal, root)
+	runIterations(traversal, 200, root)
+	oopBestResponse, ipBestResponse, exploitability := Exploitability(traversal, root)
+	assert.Less(t, exploitability, start/20)
+	assert.Greater(t, exploitability, 0.0)
+	//the players lose the rake between them, so the best responses do not sum to zero at the equilibrium
+	assert.Less(t, oopBestResponse+ipBestResponse, 0.0)
+	oopValue, ipValue := strategyValue(traversal, root, 0), strategyValue(traversal, root, 1)
+	//every pot is at least 100 chips, so the rake is between 5 chips and the cap
+	assert.InDelta(t, -6.5, oopValue+ipValue, 1.5+1e-9)
+
+	report, err := EvaluateStrategy(traversal, root, 0, StrategyOverrides{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, report.EVLoss)
+	assert.InDelta(t, report.SolverEV, report.EV, 1e-9)
+	assert.Greater(t, -ipBestResponse, report.SolverEV)
+	assert.LessOrEqual(t, report.SolverEV, oopValue+1e-9)
+
+	//without rake the values of the players sum to zero
+	unraked, unrakedTraversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	runIterations(unrakedTraversal, 200, unraked)
+	unrakedValue := strategyValue(unrakedTraversal, unraked, 0) + strategyValue(unrakedTraversal, unraked, 1)
+	assert.InDelta(t, 0.0, unrakedValue, 1e-9)
+	//so the exploitability only needs the best responses
+	assert.True(t, zeroSum(unraked))
+	oopBestResponse, ipBestResponse, exploitability = Exploitability(unrakedTraversal, unraked)
+	assert.Equal(t, (oopBestResponse+ipBestResponse)/2/unraked.potSize*100, exploitability)
+}
@@ -0,0 +1,157 @@
+package solv
+
+import (
+	"encoding/gob"
+	"fmt"
+	"github.com/chehsunliu/poker"
+	"io"
+	"math/bits"
+	"sort"
+	"sync"
+)
+
+//RankCache stores the rank of every combo on river boards. The ranks do not depend on the ranges, so one cache
+//can be shared by every tree of a board, such as the solves of a bet size sweep, and saved to disk to skip the
+//evaluation in later runs. It is safe for concurrent use, a board is evaluated once by the first goroutine asking
+//for it while later ones wait for the ranks. The cache ranks hands by the rules of the variant of its evaluator
+type RankCache struct {
+	evaluator Evaluator
+	variant   Variant
+	boards    map[uint64]*boardRanks
+	mutex     sync.Mutex
+}
+
+//boardRanks holds the rank of every combo by ComboIndex, combos overlapping the board have rank 0
+type boardRanks struct {
+	once  sync.Once
+	board []poker.Card
+	ranks []int16
+}
+
+//get returns the ranks of the board, evaluating them if no goroutine has done so yet
+func (entry *boardRanks) get(evaluator Evaluator) []int16 {
+	entry.once.Do(func() {
+		entry.ranks = evaluateBoard(evaluator, entry.board)
+	})
+	return entry.ranks
+}
+
+//rankCacheFile is the format written by Save, boards are sorted so saving the same cache gives the same bytes.
+//Files saved before the variant was recorded decode as hold'em
+type rankCacheFile struct {
+	Variant Variant
+	Boards  []uint64
+	Ranks   [][]int16
+}
+
+//NewRankCache returns an empty cache ranking boards with evaluator, or the default evaluator if it is nil
+func NewRankCache(evaluator Evaluator) *RankCache {
+	if evaluator == nil {
+		evaluator = DefaultEvaluator()
+	}
+	return &RankCache{
+		evaluator: evaluator,
+		variant:   evaluatorVariant(evaluator),
+		boards:    make(map[uint64]*boardRanks),
+	}
+}
+
+//Variant returns the variant whose rules the cache ranks hands by
+func (cache *RankCache) Variant() Variant {
+	return cache.variant
+}
+
+//Ranks returns the rank of every combo on a river board by ComboIndex, evaluating the board the first time it is
+//needed. Combos overlapping the board have rank 0, the returned slice must not be modified
+func (cache *RankCache) Ranks(board []poker.Card) []int16 {
+	key := boardMask(board)
+	cache.mutex.Lock()
+	entry, ok := cache.boards[key]
+	if !ok {
+		entry = &boardRanks{board: append([]poker.Card(nil), board...)}
+		cache.boards[key] = entry
+	}
+	cache.mutex.Unlock()
+	return entry.get(cache.evaluator)
+}
+
+//Len returns the number of boards in the cache
+func (cache *RankCache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return len(cache.boards)
+}
+
+func evaluateBoard(evaluator Evaluator, board []poker.Card) []int16 {
+	ranks := make([]int16, NumCombos)
+	mask := boardMask(board)
+	var cards [7]poker.Card
+	copy(cards[:], board)
+	for first := 1; first < 52; first++ {
+		for second := 0; second < first; second++ {
+			if mask&(1<<uint(first)|1<<uint(second)) != 0 {
+				continue
+			}
+			cards[5] = intToCard(first)
+			cards[6] = intToCard(second)
+			ranks[first*(first-1)/2+second] = int16(evaluator.Evaluate(cards[:]))
+		}
+	}
+	return ranks
+}
+
+//Save writes the ranks of every board in the cache to w
+func (cache *RankCache) Save(w io.Writer) error {
+	file := rankCacheFile{Variant: cache.variant}
+	cache.mutex.Lock()
+	entries := make([]*boardRanks, 0, len(cache.boards))
+	for key := range cache.boards {
+		file.Boards = append(file.Boards, key)
+	}
+	sort.Slice(file.Boards, func(i, j int) bool {
+		return file.Boards[i] < file.Boards[j]
+	})
+	for _, key := range file.Boards {
+		entries = append(entries, cache.boards[key])
+	}
+	cache.mutex.Unlock()
+
+	for _, entry := range entries {
+		//boards still being evaluated by another goroutine are waited for, so every saved board is complete
+		file.Ranks = append(file.Ranks, entry.get(cache.evaluator))
+	}
+	return gob.NewEncoder(w).Encode(&file)
+}
+
+//LoadRankCache reads a cache written by Save, boards missing from it are ranked with evaluator, or the default
+//evaluator if it is nil. The saved ranks must come from an evaluator ranking hands the same way, a cache saved
+//for another variant than the one of evaluator is rejected
+func LoadRankCache(r io.Reader, evaluator Evaluator) (*RankCache, error) {
+	var file rankCacheFile
+	if err := gob.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+	if len(file.Boards) != len(file.Ranks) {
+		return nil, fmt.Errorf("rank cache has %v boards but %v rankings", len(file.Boards), len(file.Ranks))
+	}
+	cache := NewRankCache(evaluator)
+	if file.Variant != cache.variant {
+		return nil, fmt.Errorf("rank cache ranks %v hands, the evaluator ranks %v hands", file.Variant, cache.variant)
+	}
+	for index, key := range file.Boards {
+		if bits.OnesCount64(key) != 5 || key>>52 != 0 {
+			return nil, fmt.Errorf("board %v of the rank cache is not a river board", index)
+		}
+		if len(file.Ranks[index]) != NumCombos {
+			return nil, fmt.Errorf("board %v of the rank cache has %v ranks, expected %v",
+				index, len(file.Ranks[index]), NumCombos)
+		}
+		ranks := file.Ranks[index]
+		entry := &boardRanks{}
+		entry.once.Do(func() {
+			entry.ranks = ranks
+		})
+		cache.boards[key] = entry
+	}
+	return cache, nil
+}
@@ -0,0 +1,105 @@
+package solv
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+//countingEvaluator counts the hands it evaluates
+type countingEvaluator struct {
+	evaluated int64
+}
+
+func (evaluator *countingEvaluator) Evaluate(cards []poker.Card) int32 {
+	atomic.AddInt64(&evaluator.evaluated, 1)
+	return DefaultEvaluator().Evaluate(cards)
+}
+
+func TestRankCacheRanks(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c"), poker.NewCard("Ts")}
+	cache := NewRankCache(nil)
+	ranks := cache.Ranks(board)
+	assert.Equal(t, NumCombos, len(ranks))
+	hand := NewHand("Ah", "Qd")
+	assert.Equal(t, poker.Evaluate(append([]poker.Card{hand[0], hand[1]}, board...)), int32(ranks[hand.ComboIndex()]))
+	assert.Equal(t, int16(0), ranks[NewHand("Kd", "3c").ComboIndex()])
+
+	//the order of the board cards does not matter
+	reversed := []poker.Card{board[4], board[3], board[2], board[1], board[0]}
+	assert.Equal(t, &ranks[0], &cache.Ranks(reversed)[0])
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestRankCacheSharedBetweenTrees(t *testing.T) {
+	evaluator := &countingEvaluator{}
+	cache := NewRankCache(evaluator)
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetRankCache(cache)
+	_, traversal := newTurnTestTree(params)
+	boards := cache.Len()
+	evaluated := atomic.LoadInt64(&evaluator.evaluated)
+	assert.True(t, boards > 0)
+
+	//a tree with other bet sizes and ranges on the same board evaluates nothing new
+	params.SetBets(2, 0, [][]float64{{0.33, 1.5}})
+	mustConstructTree(100, 200, params, traversal.Ranges[0], traversal.Ranges[1], append([]poker.Card(nil), turnBoard...))
+	assert.Equal(t, boards, cache.Len())
+	assert.Equal(t, evaluated, atomic.LoadInt64(&evaluator.evaluated))
+}
+
+func TestRankCacheSaveAndLoad(t *testing.T) {
+	cache := NewRankCache(nil)
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetRankCache(cache)
+	root, traversal := newTurnTestTree(params)
+	runIterations(traversal, 5, root)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, cache.Save(&buffer))
+	evaluator := &countingEvaluator{}
+	loaded, err := LoadRankCache(bytes.NewReader(buffer.Bytes()), evaluator)
+	assert.Nil(t, err)
+	assert.Equal(t, cache.Len(), loaded.Len())
+
+	params.SetRankCache(loaded)
+	loadedRoot, loadedTraversal := newTurnTestTree(params)
+	runIterations(loadedTraversal, 5, loadedRoot)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&evaluator.evaluated))
+	assertSameTree(t, root, loadedRoot)
+	for hand := 0; hand < root.NumHands(); hand++ {
+		assert.Equal(t, root.Regrets(hand), loadedRoot.Regrets(hand))
+	}
+
+	//saving the same boards gives the same bytes
+	var again bytes.Buffer
+	assert.Nil(t, loaded.Save(&again))
+	assert.Equal(t, buffer.Bytes(), again.Bytes())
+}
+
+func TestLoadRankCacheRejectsInvalidFiles(t *testing.T) {
+	_, err := LoadRankCache(bytes.NewReader([]byte("not a rank cache")), nil)
+	assert.NotNil(t, err)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buffer).Encode(&rankCacheFile{Boards: []uint64{0x1f}, Ranks: [][]int16{{1, 2}}}))
+	_, err = LoadRankCache(&buffer, nil)
+	assert.NotNil(t, err)
+
+	buffer.Reset()
+	assert.Nil(t, gob.NewEncoder(&buffer).Encode(&rankCacheFile{Boards: []uint64{0xf}, Ranks: [][]int16{make([]int16, NumCombos)}}))
+	_, err = LoadRankCache(&buffer, nil)
+	assert.NotNil(t, err)
+
+	//a short deck cache is only loaded for a short deck evaluator
+	var shortDeck bytes.Buffer
+	assert.Nil(t, NewRankCache(NewShortDeckEvaluator()).Save(&shortDeck))
+	_, err = LoadRankCache(bytes.NewReader(shortDeck.Bytes()), nil)
+	assert.NotNil(t, err)
+	loaded, err := LoadRankCache(bytes.NewReader(shortDeck.Bytes()), DefaultShortDeckEvaluator())
+	assert.Nil(t, err)
+	assert.Equal(t, ShortDeck, loaded.Variant())
+}
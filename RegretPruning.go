@@ -0,0 +1,95 @@
+package solv
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+const defaultPruneThreshold = 0.25
+const defaultPruneRevisit = 10
+
+//regretPruning skips the subtree of an action for the traverser hands that do not play it and whose regret for it
+//is below the threshold, in pots times the reach of the opponent at the node. The utility of the action is not
+//known for skipped hands, so their regret for it is kept as it is until the action is traversed again. Every
+//revisit iterations nothing is pruned, so the regrets of pruned actions catch up and actions that became good
+//again are played. actions and pruned count the hand actions the traverser visited and skipped
+type regretPruning struct {
+	threshold float64
+	revisit   int
+	actions   int64
+	pruned    int64
+}
+
+//SetPruning sets if the traverser skips the subtrees of actions with a large negative regret for a hand, this is
+//disabled by default
+func (traversal *Traversal) SetPruning(enabled bool) {
+	if !enabled {
+		traversal.pruning = nil
+	} else if traversal.pruning == nil {
+		traversal.pruning = &regretPruning{threshold: defaultPruneThreshold, revisit: defaultPruneRevisit}
+	}
+}
+
+//SetPruningThreshold enables pruning of the actions of a hand whose regret is below threshold pots times the reach
+//of the opponent, with every action traversed for every hand once every revisitInterval iterations
+func (traversal *Traversal) SetPruningThreshold(threshold float64, revisitInterval int) {
+	traversal.SetPruning(true)
+	traversal.pruning.threshold = threshold
+	traversal.pruning.revisit = revisitInterval
+}
+
+//PruningStats returns the number of actions of traverser hands visited so far and how many of them were pruned
+func (traversal *Traversal) PruningStats() (actions, pruned int64) {
+	if traversal.pruning == nil {
+		return 0, 0
+	}
+	return atomic.LoadInt64(&traversal.pruning.actions), atomic.LoadInt64(&traversal.pruning.pruned)
+}
+
+//pruneLimit returns the regret below which an action is pruned for a hand that does not play it at node, or
+//negative infinity if nothing is pruned in this iteration
+func (traversal *Traversal) pruneLimit(node *GameNode, opponentReachProb []float64) float64 {
+	pruning := traversal.pruning
+	if pruning == nil || node.locked || pruning.revisit <= 0 || traversal.Iteration%pruning.revisit == 0 {
+		return math.Inf(-1)
+	}
+	opponentReach := 0.0
+	for _, hand := range traversal.activeHands(traversal.Traverser ^ 1) {
+		opponentReach += opponentReachProb[hand]
+	}
+	return -pruning.threshold * node.potSize * opponentReach
+}
+
+//unprunedHands writes the hands of active that still traverse the child of action to buffer and returns them,
+//a hand is pruned if it does not play the action and its regret for it is below limit
+func (values *nodeValues) unprunedHands(buffer *[]int, active []int, action int, limit float64) []int {
+	hands := (*buffer)[:0]
+	for _, hand := range active {
+		if !values.pruned(hand, action, limit) {
+			hands = append(hands, hand)
+		}
+	}
+	*buffer = hands
+	return hands
+}
+
+func (values *nodeValues) pruned(hand, action int, limit float64) bool {
+	return values.strategy(hand, action) == 0 && values.regret(hand, action) < limit
+}
+
+//prune returns the active traverser hands of the child of every action of node, or nil if nothing is pruned, and
+//counts the visited and pruned hand actions
+func (traversal *Traversal) prune(node *GameNode, level *scratchLevel, hands []int, limit float64) [][]int {
+	if math.IsInf(limit, -1) {
+		atomic.AddInt64(&traversal.pruning.actions, int64(len(hands)*node.numActions))
+		return nil
+	}
+	nextActive := level.actionActive(node.numActions)
+	pruned := 0
+	for i := range nextActive {
+		pruned += len(hands) - len(node.values.unprunedHands(&nextActive[i], hands, i, limit))
+	}
+	atomic.AddInt64(&traversal.pruning.actions, int64(len(hands)*node.numActions))
+	atomic.AddInt64(&traversal.pruning.pruned, int64(pruned))
+	return nextActive
+}
@@ -0,0 +1,52 @@
+package solv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPruningSkipsActionsAndStillConverges(t *testing.T) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	runIterations(traversal, 200, root)
+	_, _, exploitability := Exploitability(traversal, root)
+
+	prunedRoot, prunedTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	prunedTrav.SetPruning(true)
+	runIterations(prunedTrav, 200, prunedRoot)
+	_, _, prunedExploitability := Exploitability(prunedTrav, prunedRoot)
+
+	actions, pruned := prunedTrav.PruningStats()
+	assert.Greater(t, pruned, int64(0))
+	assert.Less(t, pruned, actions)
+	assert.InDelta(t, exploitability, prunedExploitability, 0.05)
+}
+
+func TestPruningRevisitingEveryIterationPrunesNothing(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Close()
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	traversal.SetWorkerPool(pool)
+	runIterations(traversal, 20, root)
+
+	prunedRoot, prunedTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	prunedTrav.SetWorkerPool(pool)
+	prunedTrav.SetPruningThreshold(0, 1)
+	runIterations(prunedTrav, 20, prunedRoot)
+
+	actions, pruned := prunedTrav.PruningStats()
+	assert.Greater(t, actions, int64(0))
+	assert.Equal(t, int64(0), pruned)
+	assertSameValues(t, root, prunedRoot)
+}
+
+func TestSetPruning(t *testing.T) {
+	traversal := NewTraversal(nil, nil)
+	traversal.SetPruningThreshold(0.5, 4)
+	traversal.SetPruning(true)
+	assert.Equal(t, 0.5, traversal.pruning.threshold)
+	assert.Equal(t, 4, traversal.pruning.revisit)
+	traversal.SetPruning(false)
+	actions, pruned := traversal.PruningStats()
+	assert.Equal(t, int64(0), actions)
+	assert.Equal(t, int64(0), pruned)
+}
@@ -0,0 +1,121 @@
+package solv
+
+import (
+	"fmt"
+	"github.com/chehsunliu/poker"
+)
+
+//ResolveGadget re-solves the strategy of Player in a subgame of a solved tree, for example with other bets, without
+//making it more exploitable. The gadget tree starts with a node of the opponent where every opponent hand either
+//terminates and takes Values, the counterfactual value of its best response in the subgame of the solved tree, or
+//follows into the rebuilt subgame. The re-solved strategy gains nothing by letting an opponent hand do better than
+//it could against the solved strategy, so once solved no opponent hand does better against it. A naive re-solve of
+//the SubgameSpot assumes the opponent plays into the subgame with its solved ranges, and can be exploited by an
+//opponent changing how it gets there
+type ResolveGadget struct {
+	Spot   *SubgameSpot
+	Player int
+	Values []float64
+}
+
+//NewResolveGadget follows path through the solved tree like NewSubgameSpot and returns the gadget re-solving the
+//strategy of player at the spot it ends at. Values holds an entry for every hand of the opponent range of the spot
+func NewResolveGadget(root *GameNode, traversal *Traversal, board []poker.Card, path []int,
+	player int) (*ResolveGadget, error) {
+	line, err := followLine(root, traversal, board, path)
+	if err != nil {
+		return nil, err
+	}
+	spot, indexes := line.spot(traversal)
+	opponent := player ^ 1
+	//the best response runs over the hands of the stored subtree
+	storedReach := make([]float64, len(line.reach[player]))
+	for hand, reach := range line.reach[player] {
+		storedReach[line.handToStored[player][hand]] = reach
+	}
+	traverser := traversal.Traverser
+	traversal.Traverser = opponent
+	storedValues := line.start.BestResponse(traversal, storedReach)
+	traversal.Traverser = traverser
+
+	values := make([]float64, len(indexes[opponent]))
+	for index, hand := range indexes[opponent] {
+		values[index] = storedValues[line.handToStored[opponent][hand]]
+	}
+	return &ResolveGadget{Spot: spot, Player: player, Values: values}, nil
+}
+
+//ConstructTree builds the gadget tree with the subgame of the spot built with params. The root is the opponent
+//node choosing between terminating and the subgame, see Subgame
+func (gadget *ResolveGadget) ConstructTree(params *ConstructionParams) (*GameNode, error) {
+	subgame, err := gadget.Spot.ConstructTree(params)
+	if err != nil {
+		return nil, err
+	}
+	opponent := gadget.Player ^ 1
+	root := NewGameNode(opponent, subgame.potSize, subgame.ipPlayerStack, subgame.oopPlayerStack)
+	root.AddNextNode(NewGadgetTerminalNode(opponent, gadget.Values))
+	root.AddNextNode(subgame)
+	root.numActions = len(root.nextNodes)
+	root.initializeHandValues(len(gadget.Spot.Ranges[opponent]), params.singlePrecision)
+	return root, nil
+}
+
+//NewTraversal returns a traversal over the ranges of the spot
+func (gadget *ResolveGadget) NewTraversal() *Traversal {
+	return gadget.Spot.NewTraversal()
+}
+
+//Subgame returns the subgame of a gadget tree built by ConstructTree, its strategies of the resolving player are
+//the re-solved ones
+func (gadget *ResolveGadget) Subgame(root *GameNode) *GameNode {
+	return root.nextNodes[1].(*GameNode)
+}
+
+//OpponentValues returns the counterfactual value of the best response of every opponent hand against the strategy of
+//the resolving player in the subgame of root. The re-solve is safe for the hands whose value is at most their value
+//in Values
+func (gadget *ResolveGadget) OpponentValues(traversal *Traversal, root *GameNode) []float64 {
+	reach := convertRangeToFloatSlice(gadget.Spot.Ranges[gadget.Player])
+	traverser := traversal.Traverser
+	traversal.Traverser = gadget.Player ^ 1
+	values := gadget.Subgame(root).BestResponse(traversal, reach)
+	traversal.Traverser = traverser
+	return values
+}
+
+//GadgetTerminalNode is where the opponent of the resolving player terminates in a gadget tree, the opponent gets its
+//value in the solved tree. The resolving player has no decision before the node and gets nothing
+type GadgetTerminalNode struct {
+	opponent int
+	values   []float64
+}
+
+func NewGadgetTerminalNode(opponent int, values []float64) *GadgetTerminalNode {
+	return &GadgetTerminalNode{opponent: opponent, values: values}
+}
+
+func (node *GadgetTerminalNode) CFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
+	utility := traversal.level().utility(traversal.utilitySize())
+	if traversal.simultaneous {
+		copy(traversal.playerUtility(utility, node.opponent), node.values)
+	} else if traversal.Traverser == node.opponent {
+		copy(utility, node.values)
+	}
+	return utility
+}
+
+func (node *GadgetTerminalNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
+	utility := make([]float64, len(traversal.Ranges[traversal.Traverser]))
+	if traversal.Traverser == node.opponent {
+		copy(utility, node.values)
+	}
+	return utility
+}
+
+func (node *GadgetTerminalNode) PrintNodeDetails(level int) {
+	for i := 0; i < level; i++ {
+		fmt.Print("\t")
+	}
+	fmt.Printf("GadgetTerminalNode opponent %v\n", node.opponent)
+}
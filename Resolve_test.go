@@ -0,0 +1,59 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestResolveGadget(t *testing.T) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	runIterations(traversal, 60, root)
+	checkBack := root.GetNext(0).(*GameNode).GetNext(0).(*ChanceNode)
+	path := []int{0, 0, checkBack.CardIndex(poker.NewCard("Qh"))}
+
+	_, err := NewResolveGadget(root, traversal, turnBoard, []int{0}, 0)
+	assert.NotNil(t, err)
+
+	for player := 0; player < 2; player++ {
+		opponent := player ^ 1
+		//the resolving player gets a smaller river bet, the solved strategy can still be played
+		params := NewConstructionParams(0.75, 1.2)
+		params.SetBets(3, player, [][]float64{{0.33, 0.75}})
+		gadget, err := NewResolveGadget(root, traversal, turnBoard, path, player)
+		assert.Nil(t, err)
+		spot := gadget.Spot
+		assert.Len(t, gadget.Values, len(spot.Ranges[opponent]))
+
+		gadgetRoot, err := gadget.ConstructTree(params)
+		assert.Nil(t, err)
+		assert.Equal(t, opponent, gadgetRoot.PlayerNode())
+		assert.Equal(t, 2, gadgetRoot.NumActions())
+		assert.Equal(t, gadgetRoot.GetNext(1), gadget.Subgame(gadgetRoot))
+		gadgetTraversal := gadget.NewTraversal()
+		runIterations(gadgetTraversal, 1000, gadgetRoot)
+		values := gadget.OpponentValues(gadgetTraversal, gadgetRoot)
+
+		naive, err := spot.ConstructTree(params)
+		assert.Nil(t, err)
+		naiveTraversal := spot.NewTraversal()
+		runIterations(naiveTraversal, 1000, naive)
+		naiveTraversal.Traverser = opponent
+		naiveValues := naive.BestResponse(naiveTraversal, convertRangeToFloatSlice(spot.Ranges[player]))
+
+		//no opponent hand does better against the re-solved strategy than against the solved one, some do against
+		//a naive re-solve
+		gain, naiveGain := math.Inf(-1), math.Inf(-1)
+		for hand := range spot.Ranges[opponent] {
+			gain = math.Max(gain, values[hand]-gadget.Values[hand])
+			naiveGain = math.Max(naiveGain, naiveValues[hand]-gadget.Values[hand])
+		}
+		reach := 0.0
+		for _, combo := range spot.Ranges[player] {
+			reach += combo.Combos
+		}
+		assert.Less(t, gain, 0.001*reach*spot.PotSize)
+		assert.Greater(t, naiveGain, 0.01*reach*spot.PotSize)
+	}
+}
@@ -3,39 +3,102 @@ package solv
 import (
 	"github.com/chehsunliu/poker"
 	"sort"
+	"sync"
 )
 
+//RiverEvaluationCache holds the hand rankings of every river board and the equity tables of all in boards of a
+//tree for its ranges, built from the ranks of its RankCache. It is safe for concurrent use, the rankings of a board are evaluated once by the
+//first goroutine inserting it while later ones wait for them
 type RiverEvaluationCache struct {
 	ipRange Range
 	oopRange Range
-	indexCache map[[5]poker.Card]int
+	ranks *RankCache
+	//variant is the game of the tree, its deck gives the runouts of the boards
+	variant Variant
+	//startingCards is the number of cards of the board the tree starts from
+	startingCards int
+	indexCache map[uint64]int
 	RankingCache [][2][]HandRankPair
+	fills []*sync.Once
+	equityTables map[uint64]*equityTableEntry
+	mutex sync.Mutex
+}
+
+type equityTableEntry struct {
+	once sync.Once
+	table *EquityTable
 }
 
 func NewRiverEvaluationCache(oopRange, ipRange Range) *RiverEvaluationCache{
 	return &RiverEvaluationCache{
 		ipRange: ipRange,
 		oopRange: oopRange,
-		indexCache: make(map[[5]poker.Card]int, 50),
+		ranks: NewRankCache(nil),
+		indexCache: make(map[uint64]int, 50),
 		RankingCache: make([][2][]HandRankPair, 0, 50),
+		equityTables: make(map[uint64]*equityTableEntry),
 	}
 }
 
-func (cache *RiverEvaluationCache) InsertBoard(board []poker.Card) int {
-	sort.Slice(board, func(i, j int) bool {
-		return board[i] < board[j]
+//EquityTable returns the all in equity table of a flop or turn board, building it the first time it is needed
+func (cache *RiverEvaluationCache) EquityTable(board []poker.Card) *EquityTable {
+	key := boardMask(board)
+	cache.mutex.Lock()
+	entry, ok := cache.equityTables[key]
+	if !ok {
+		entry = &equityTableEntry{}
+		cache.equityTables[key] = entry
+	}
+	cache.mutex.Unlock()
+	entry.once.Do(func() {
+		entry.table = newEquityTable(board, cache.oopRange, cache.ipRange, cache.ranks, cache.variant)
 	})
-	var boardCopy [5]poker.Card
-	copy(boardCopy[:], board)
-	if index, ok := cache.indexCache[boardCopy]; ok {
+	return entry.table
+}
+
+//reserveRunouts gives every river runout of board its index in RankingCache in the order of the runouts, so the
+//indexes do not depend on the order in which goroutines building the tree insert the boards. The rankings are
+//only evaluated once a board is inserted
+func (cache *RiverEvaluationCache) reserveRunouts(board []poker.Card) {
+	runouts := [][]poker.Card{board}
+	if len(board) < 5 {
+		runouts = constructPossibleRunouts(board, cache.variant)
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	for _, runout := range runouts {
+		cache.reserveBoard(boardMask(runout))
+	}
+}
+
+//reserveBoard returns the index of the board with the given mask, adding an empty entry if the board is new.
+//The mutex must be held
+func (cache *RiverEvaluationCache) reserveBoard(key uint64) int {
+	if index, ok := cache.indexCache[key]; ok {
 		return index
 	}
-	rankings := cache.FillHandRankings(board)
-	cache.RankingCache = append(cache.RankingCache, rankings)
-	cache.indexCache[boardCopy] = len(cache.RankingCache) - 1
+	cache.RankingCache = append(cache.RankingCache, [2][]HandRankPair{})
+	cache.fills = append(cache.fills, &sync.Once{})
+	cache.indexCache[key] = len(cache.RankingCache) - 1
 	return len(cache.RankingCache) - 1
 }
 
+//InsertBoard returns the index of the hand rankings of a river board in RankingCache, boards are keyed by the bit
+//set of their card indexes so the order of the cards does not matter
+func (cache *RiverEvaluationCache) InsertBoard(board []poker.Card) int {
+	cache.mutex.Lock()
+	index := cache.reserveBoard(boardMask(board))
+	fill := cache.fills[index]
+	cache.mutex.Unlock()
+	fill.Do(func() {
+		rankings := cache.FillHandRankings(board)
+		cache.mutex.Lock()
+		cache.RankingCache[index] = rankings
+		cache.mutex.Unlock()
+	})
+	return index
+}
+
 func (cache *RiverEvaluationCache) FillHandRankings(board []poker.Card) [2][]HandRankPair {
 	ipRanks := cache.fillIPHandRankings(board)
 	oopRanks := cache.fillOOPHandRankings(board)
@@ -55,25 +118,25 @@ func (cache *RiverEvaluationCache) FillHandRankings(board []poker.Card) [2][]Han
 }
 
 func (cache *RiverEvaluationCache) fillIPHandRankings(board []poker.Card) []HandRankPair {
-	ipRanks := make([]HandRankPair,0)
-	for i := range cache.ipRange {
-		if !CheckHandBoardOverlap(cache.ipRange[i].Hand, board) {
-			finalHand := append(board, cache.ipRange[i].Hand[0])
-			finalHand = append(finalHand, cache.ipRange[i].Hand[1])
-			ipRanks = append(ipRanks, HandRankPair{cache.ipRange[i].Hand, poker.Evaluate(finalHand)})
-		}
-	}
-	return ipRanks
+	return rangeRankings(cache.ipRange, board, cache.ranks.Ranks(board))
 }
 
 func (cache *RiverEvaluationCache) fillOOPHandRankings(board []poker.Card) []HandRankPair {
-	oopRanks := make([]HandRankPair, 0)
-	for i := range cache.oopRange {
-		if !CheckHandBoardOverlap(cache.oopRange[i].Hand, board) {
-			finalHand := append(board, cache.oopRange[i].Hand[0])
-			finalHand = append(finalHand, cache.oopRange[i].Hand[1])
-			oopRanks = append(oopRanks, HandRankPair{cache.oopRange[i].Hand, poker.Evaluate(finalHand)})
+	return rangeRankings(cache.oopRange, board, cache.ranks.Ranks(board))
+}
+
+//rangeRankings pairs every hand of rng that does not overlap the board with its rank in ranks
+func rangeRankings(rng Range, board []poker.Card, ranks []int16) []HandRankPair {
+	rankings := make([]HandRankPair, 0, len(rng))
+	for i := range rng {
+		if !CheckHandBoardOverlap(rng[i].Hand, board) {
+			rankings = append(rankings, HandRankPair{
+				Hand: rng[i].Hand,
+				Rank: int32(ranks[rng[i].Hand.ComboIndex()]),
+				Index: i,
+				cards: rng[i].Hand.CardIndexes(),
+			})
 		}
 	}
-	return oopRanks
-}
\ No newline at end of file
+	return rankings
+}
@@ -0,0 +1,52 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestInsertBoardConcurrently(t *testing.T) {
+	turn := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c")}
+	oop := RemoveConflicts(HandsStringToHandRange("TT+, AQs+, KQs, AK"), turn)
+	ip := RemoveConflicts(HandsStringToHandRange("99+, ATs+, KTs+, QJs, AQ+"), turn)
+	runouts := constructPossibleRunouts(turn, Holdem)
+
+	cache := NewRiverEvaluationCache(oop, ip)
+	indexes := make([][]int, 8)
+	var wg sync.WaitGroup
+	for worker := range indexes {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			indexes[worker] = make([]int, len(runouts))
+			//every worker inserts the boards in a different order
+			for i := range runouts {
+				board := (i + worker*7) % len(runouts)
+				indexes[worker][board] = cache.InsertBoard(runouts[board])
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	assert.Equal(t, len(runouts), len(cache.RankingCache))
+	for worker := range indexes {
+		assert.Equal(t, indexes[0], indexes[worker])
+	}
+	for board, runout := range runouts {
+		assert.Equal(t, cache.FillHandRankings(runout), cache.RankingCache[indexes[0][board]])
+	}
+}
+
+func TestReservedRunoutsKeepTheirOrder(t *testing.T) {
+	turn := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c")}
+	runouts := constructPossibleRunouts(turn, Holdem)
+	cache := NewRiverEvaluationCache(HandsStringToHandRange("AA"), HandsStringToHandRange("KQs"))
+	cache.reserveRunouts(turn)
+	last := runouts[len(runouts)-1]
+	reversed := []poker.Card{last[4], last[3], last[2], last[1], last[0]}
+	assert.Equal(t, len(runouts)-1, cache.InsertBoard(reversed))
+	assert.Equal(t, 0, cache.InsertBoard(runouts[0]))
+	assert.Equal(t, len(runouts), len(cache.RankingCache))
+}
@@ -0,0 +1,140 @@
+package solv
+
+import "sync"
+
+//scratchLevel holds the buffers used by the node at one depth of a traversal. A node only writes to the level of
+//its own depth, so the buffers of a parent stay valid while its children are traversed, and the utility a node
+//returns stays valid until the next node at the same depth is traversed
+type scratchLevel struct {
+	utilityBuffer []float64
+	reachBuffer   []float64
+	actionBuffers [][]float64
+	reachBuffers  [][]float64
+	totalsBuffer  []float64
+	activeBuffers [][]int
+	playerViews   [][]float64
+	sampled       []int
+	bucketBuffers [][]int
+
+	//buffers used by a worker traversing the subtree of a dealt card
+	dealtReach     [2][]float64
+	canonicalReach [2][]float64
+	removedReach   []float64
+	dealtActive    [2][]int
+
+	cardResults [][]float64
+	wg          sync.WaitGroup
+}
+
+//level returns the scratch buffers of the current depth, they are allocated the first time a depth is reached and
+//reused by every later traversal
+func (traversal *Traversal) level() *scratchLevel {
+	for len(traversal.levels) <= traversal.depth {
+		traversal.levels = append(traversal.levels, &scratchLevel{})
+	}
+	return traversal.levels[traversal.depth]
+}
+
+//fork returns a traversal for a worker goroutine that shares everything with traversal except the scratch
+//buffers, it must be given back with release once the worker's results are copied out
+func (traversal *Traversal) fork() *Traversal {
+	worker := traversal.forks.Get().(*Traversal)
+	levels := worker.levels
+	*worker = *traversal
+	worker.levels = levels
+	worker.depth++
+	return worker
+}
+
+func (traversal *Traversal) release(worker *Traversal) {
+	traversal.forks.Put(worker)
+}
+
+func newForkPool() *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			return &Traversal{}
+		},
+	}
+}
+
+//resize returns buffer with length n, reallocating it only if it is too small
+func resize(buffer []float64, n int) []float64 {
+	if cap(buffer) < n {
+		return make([]float64, n)
+	}
+	return buffer[:n]
+}
+
+//utility returns a zeroed buffer of length n for the utility a node returns
+func (level *scratchLevel) utility(n int) []float64 {
+	level.utilityBuffer = resize(level.utilityBuffer, n)
+	for i := range level.utilityBuffer {
+		level.utilityBuffer[i] = 0
+	}
+	return level.utilityBuffer
+}
+
+//reach returns a buffer of length n for the reach probabilities passed to a child node
+func (level *scratchLevel) reach(n int) []float64 {
+	level.reachBuffer = resize(level.reachBuffer, n)
+	return level.reachBuffer
+}
+
+//actionUtilities returns a buffer of length n for the utility of each of numActions actions
+func (level *scratchLevel) actionUtilities(numActions, n int) [][]float64 {
+	level.actionBuffers = resizeAll(level.actionBuffers, numActions, n)
+	return level.actionBuffers[:numActions]
+}
+
+//actionReaches returns a buffer of length n for the reach probabilities after each of numActions actions
+func (level *scratchLevel) actionReaches(numActions, n int) [][]float64 {
+	level.reachBuffers = resizeAll(level.reachBuffers, numActions, n)
+	return level.reachBuffers[:numActions]
+}
+
+//resizeAll resizes the first count buffers to length n, appending buffers if there are fewer than count
+func resizeAll(buffers [][]float64, count, n int) [][]float64 {
+	for len(buffers) < count {
+		buffers = append(buffers, nil)
+	}
+	for i := 0; i < count; i++ {
+		buffers[i] = resize(buffers[i], n)
+	}
+	return buffers
+}
+
+//actionViews returns a buffer for numActions slices viewing the action utilities of one player
+func (level *scratchLevel) actionViews(numActions int) [][]float64 {
+	for len(level.playerViews) < numActions {
+		level.playerViews = append(level.playerViews, nil)
+	}
+	return level.playerViews[:numActions]
+}
+
+//actionActive returns a buffer for the active opponent hands after each of numActions actions
+func (level *scratchLevel) actionActive(numActions int) [][]int {
+	for len(level.activeBuffers) < numActions {
+		level.activeBuffers = append(level.activeBuffers, nil)
+	}
+	return level.activeBuffers[:numActions]
+}
+
+//bucketSamples returns a buffer for the sampled cards of each of numBuckets buckets of a chance node
+func (level *scratchLevel) bucketSamples(numBuckets int) [][]int {
+	for len(level.bucketBuffers) < numBuckets {
+		level.bucketBuffers = append(level.bucketBuffers, nil)
+	}
+	return level.bucketBuffers[:numBuckets]
+}
+
+func (level *scratchLevel) actionTotals(numActions int) []float64 {
+	level.totalsBuffer = resize(level.totalsBuffer, numActions)
+	return level.totalsBuffer
+}
+
+//results returns numCards buffers of length n for the results of every card dealt at a chance node
+func (level *scratchLevel) results(numCards, n int) [][]float64 {
+	level.cardResults = resizeAll(level.cardResults, numCards, n)
+	return level.cardResults[:numCards]
+}
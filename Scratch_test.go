@@ -0,0 +1,41 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newBenchmarkRiverTree() (*GameNode, *Traversal) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c"), poker.NewCard("Ts")}
+	oop := RemoveConflicts(HandsStringToHandRange("22+, A2s+, K9s+, QTs+, JTs, ATo+, KJo+"), board)
+	ip := RemoveConflicts(HandsStringToHandRange("22+, A2s+, K2s+, Q8s+, J8s+, T8s+, 97s+, 87s, A8o+, KTo+, QTo+"), board)
+	return mustConstructTree(100, 400, NewConstructionParams(0.75, 1.2), ip, oop, board), NewTraversal(oop, ip)
+}
+
+func TestCFRTraversalReusesScratchBuffers(t *testing.T) {
+	root, traversal := newBenchmarkRiverTree()
+	runIterations(traversal, 1, root)
+	allocations := testing.AllocsPerRun(10, func() {
+		runIterations(traversal, 1, root)
+	})
+	//only the two reach slices of runIterations are allocated, the traversal allocates nothing
+	assert.Equal(t, 2.0, allocations)
+}
+
+func benchmarkCFRIteration(b *testing.B, root *GameNode, traversal *Traversal) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	runIterations(traversal, b.N, root)
+}
+
+func BenchmarkCFRIterationRiver(b *testing.B) {
+	root, traversal := newBenchmarkRiverTree()
+	benchmarkCFRIteration(b, root, traversal)
+}
+
+//the turn iteration deals cards on the default worker pool
+func BenchmarkCFRIterationTurn(b *testing.B) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	benchmarkCFRIteration(b, root, traversal)
+}
@@ -6,10 +6,12 @@ import (
 )
 
 //HandRankPair pairs a hand with its Rank, used for the O(N) showdown evaluation using a sorted list of
-//ranks
+//ranks. Index is the index of the hand in its player's range and cards the index of both of its cards
 type HandRankPair struct {
 	Hand Hand
 	Rank int32
+	Index int
+	cards [2]int
 }
 
 //ShowdownNode is a GameNode that occurs in the game tree after the prior node action is either the IP player
@@ -17,7 +19,10 @@ type HandRankPair struct {
 //comparing vs the opponents set of hands
 type ShowdownNode struct {
 	lastPlayer int
+	potSize float64
 	winUtility float64
+	rakeUtility float64
+	icm *[2]payoff
 	cacheIndex int
 	cache *RiverEvaluationCache
 	board []poker.Card
@@ -29,49 +34,76 @@ func NewShowdownNode(potSize float64, lastPlayer int, board []poker.Card, cache
 		lastPlayer: lastPlayer,
 		cache: cache,
 	}
+	node.potSize = potSize
 	node.winUtility = potSize / 2.0
 	node.board = board
 	node.cacheIndex = 0
 	return &node
 }
 
+//setRake takes rake from the pot of the showdown, the winner gets the pot minus the rake and a tie splits it
+func (node *ShowdownNode) setRake(rake float64) {
+	node.winUtility, node.rakeUtility = rakeUtilities(node.potSize, rake)
+}
+
 func (node *ShowdownNode) CFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
-	return node.GetUtil(traversal, opponentReachProb)
+	utility := traversal.level().utility(traversal.utilitySize())
+	if traversal.simultaneous {
+		reach := [2][]float64{traverserReachProb, opponentReachProb}
+		for player := range reach {
+			node.showdownInto(traversal, player, traversal.playerUtility(utility, player), reach[player^1])
+		}
+		return utility
+	}
+	node.showdownInto(traversal, traversal.Traverser, utility, opponentReachProb)
+	return utility
 }
 
 //GetUtil calculates the utility for the traverser with the efficient algorithm
 func (node *ShowdownNode) GetUtil(traversal *Traversal, opponentReachProb []float64) []float64 {
-	if traversal.Traverser == 1 {
-		return node.Showdown(traversal, node.cache.RankingCache[node.cacheIndex][1],
-			node.cache.RankingCache[node.cacheIndex][0], opponentReachProb)
+	utility := make([]float64, len(traversal.Ranges[traversal.Traverser]))
+	node.showdownInto(traversal, traversal.Traverser, utility, opponentReachProb)
+	return utility
+}
+
+//showdownInto writes the utility of every hand of traverser to utility, which must be zeroed
+func (node *ShowdownNode) showdownInto(traversal *Traversal, traverser int, utility, opponentReachProb []float64) {
+	traverserRanks := node.cache.RankingCache[node.cacheIndex][traverser]
+	opponentRanks := node.cache.RankingCache[node.cacheIndex][traverser^1]
+	//ties get the tie payoff of every opponent hand, the hands winning or losing get the difference on top
+	payoff := nodePayoff(traversal, node.icm, traverser, node.winUtility, node.rakeUtility)
+	node.winnerShowdownProbabilityCalculation(traversal, utility, opponentReachProb, traverserRanks, opponentRanks,
+		payoff.win-payoff.tie)
+	node.loserShowdownProbabilityCalculation(traversal, utility, opponentReachProb, traverserRanks, opponentRanks,
+		payoff.tie-payoff.lose)
+	if payoff.tie != 0 {
+		opponentReachUtility(traversal, traverser, node.board, utility, opponentReachProb, payoff.tie)
 	}
-	return node.Showdown(traversal, node.cache.RankingCache[node.cacheIndex][0],
-		node.cache.RankingCache[node.cacheIndex][1], opponentReachProb)
 }
 
 func (node *ShowdownNode) PrintNodeDetails(level int) {
 	for i := 0; i < level; i++ {
 		fmt.Print("\t")
 	}
-	fmt.Printf("Showdown node: last: %v pot %v\n", node.lastPlayer, node.winUtility * 2.0)
+	fmt.Printf("Showdown node: last: %v pot %v\n", node.lastPlayer, node.potSize)
 }
 
 //Showdown calculates the hand utility for the traverser using the O(n) evaluation algorithm
 func (node *ShowdownNode) Showdown(traversal *Traversal, TraverserRanks,
 	 							   OpponentRanks []HandRankPair, opponentReachProb []float64) []float64 {
 	utility := make([]float64, len(traversal.Ranges[traversal.Traverser]))
-	node.winnerShowdownProbabilityCalculation(traversal, utility, opponentReachProb, TraverserRanks, OpponentRanks)
-	node.loserShowdownProbabilityCalculation(traversal, utility, opponentReachProb, TraverserRanks, OpponentRanks)
+	node.winnerShowdownProbabilityCalculation(traversal, utility, opponentReachProb, TraverserRanks, OpponentRanks,
+		node.winUtility)
+	node.loserShowdownProbabilityCalculation(traversal, utility, opponentReachProb, TraverserRanks, OpponentRanks,
+		node.winUtility)
 	return utility
 }
 
 func (node *ShowdownNode) winnerShowdownProbabilityCalculation(traversal *Traversal, utility, OpponentReachProb []float64,
-															   TraverserRanks, OpponentRanks []HandRankPair) {
-	cardRemoval := make(map[poker.Card]float64)
+															   TraverserRanks, OpponentRanks []HandRankPair, winUtility float64) {
+	var cardRemoval [52]float64
 	winnerProbabilitySum := 0.0
 
-	opponent := traversal.Traverser ^ 1
-
 	opIndex := 0
 
 	//iterate through all of the traverser's hands, and then while we have a better hand (lower rank) increase
@@ -82,27 +114,26 @@ func (node *ShowdownNode) winnerShowdownProbabilityCalculation(traversal *Traver
 		probably low hanging fruit
 		*/
 		for opIndex < len(OpponentRanks) && OpponentRanks[opIndex].Rank > TraverserRanks[traverserRankIndex].Rank {
-			prob := OpponentReachProb[traversal.IndexCaches[opponent][OpponentRanks[opIndex].Hand]]
-			winnerProbabilitySum += prob
-			cardRemoval[OpponentRanks[opIndex].Hand[0]] += prob
-			cardRemoval[OpponentRanks[opIndex].Hand[1]] += prob
-
+			//hands without reach, such as the ones the opponent folded, add nothing
+			if prob := OpponentReachProb[OpponentRanks[opIndex].Index]; prob != 0 {
+				winnerProbabilitySum += prob
+				cardRemoval[OpponentRanks[opIndex].cards[0]] += prob
+				cardRemoval[OpponentRanks[opIndex].cards[1]] += prob
+			}
 			opIndex++
 		}
-		utility[traversal.IndexCaches[traversal.Traverser][TraverserRanks[traverserRankIndex].Hand]] =
+		utility[TraverserRanks[traverserRankIndex].Index] =
 			(winnerProbabilitySum -
-				cardRemoval[TraverserRanks[traverserRankIndex].Hand[0]] -
-				cardRemoval[TraverserRanks[traverserRankIndex].Hand[1]]) * node.winUtility
+				cardRemoval[TraverserRanks[traverserRankIndex].cards[0]] -
+				cardRemoval[TraverserRanks[traverserRankIndex].cards[1]]) * winUtility
 	}
 }
 
 func (node *ShowdownNode) loserShowdownProbabilityCalculation(traversal *Traversal, utility, OpponentReachProb []float64,
-														      TraverserRanks, OpponentRanks []HandRankPair) {
-	cardRemoval := make(map[poker.Card]float64)
+														      TraverserRanks, OpponentRanks []HandRankPair, loseUtility float64) {
+	var cardRemoval [52]float64
 	loserProbabilitySum := 0.0
 
-	opponent := traversal.Traverser ^ 1
-
 	opIndex := len(OpponentRanks) - 1
 
 	//iterate through all of the traverser's hands, and then while we have a better hand (lower rank) increase
@@ -113,18 +144,17 @@ func (node *ShowdownNode) loserShowdownProbabilityCalculation(traversal *Travers
 		probably low hanging fruit
 		*/
 		for opIndex >= 0 && OpponentRanks[opIndex].Rank < TraverserRanks[traverserRankIndex].Rank {
-			prob := OpponentReachProb[traversal.IndexCaches[opponent][OpponentRanks[opIndex].Hand]]
-
-			loserProbabilitySum += prob
-			cardRemoval[OpponentRanks[opIndex].Hand[0]] += prob
-			cardRemoval[OpponentRanks[opIndex].Hand[1]] += prob
-
+			if prob := OpponentReachProb[OpponentRanks[opIndex].Index]; prob != 0 {
+				loserProbabilitySum += prob
+				cardRemoval[OpponentRanks[opIndex].cards[0]] += prob
+				cardRemoval[OpponentRanks[opIndex].cards[1]] += prob
+			}
 			opIndex--
 		}
-		utility[traversal.IndexCaches[traversal.Traverser][TraverserRanks[traverserRankIndex].Hand]] -=
+		utility[TraverserRanks[traverserRankIndex].Index] -=
 			(loserProbabilitySum -
-				cardRemoval[TraverserRanks[traverserRankIndex].Hand[0]] -
-				cardRemoval[TraverserRanks[traverserRankIndex].Hand[1]]) * node.winUtility
+				cardRemoval[TraverserRanks[traverserRankIndex].cards[0]] -
+				cardRemoval[TraverserRanks[traverserRankIndex].cards[1]]) * loseUtility
 	}
 }
 
@@ -132,37 +162,22 @@ func (node *ShowdownNode) ShowdownSlow(traversal *Traversal, TraverserRanks,
 									   OpponentRanks []HandRankPair, opponentReachProb []float64) []float64 {
 	utility := make([]float64, len(TraverserRanks))
 
-	op := traversal.Traverser ^ 1
-	trav := traversal.Traverser
-
 	for traverserIndex := range TraverserRanks {
 		probabilitySum := 0.0
 		for oppIndex := range OpponentRanks {
 			if !CheckHandOverlap(OpponentRanks[oppIndex].Hand, TraverserRanks[traverserIndex].Hand) {
 				if OpponentRanks[oppIndex].Rank > TraverserRanks[traverserIndex].Rank {
-					probabilitySum += opponentReachProb[traversal.IndexCaches[op][OpponentRanks[oppIndex].Hand]]
+					probabilitySum += opponentReachProb[OpponentRanks[oppIndex].Index]
 				} else if OpponentRanks[oppIndex].Rank < TraverserRanks[traverserIndex].Rank {
-					probabilitySum -= opponentReachProb[traversal.IndexCaches[op][OpponentRanks[oppIndex].Hand]]
+					probabilitySum -= opponentReachProb[OpponentRanks[oppIndex].Index]
 				}
 			}
 		}
-		utility[traversal.IndexCaches[trav][TraverserRanks[traverserIndex].Hand]] = probabilitySum * node.winUtility
+		utility[TraverserRanks[traverserIndex].Index] = probabilitySum * node.winUtility
 	}
 	return utility
 }
 
 func (node *ShowdownNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
-	var TraverserRanks []HandRankPair
-	var OpponentRanks []HandRankPair
-	if traversal.Traverser == 0 {
-		TraverserRanks = node.cache.RankingCache[node.cacheIndex][0]
-		OpponentRanks = node.cache.RankingCache[node.cacheIndex][1]
-	} else {
-		OpponentRanks = node.cache.RankingCache[node.cacheIndex][0]
-		TraverserRanks = node.cache.RankingCache[node.cacheIndex][1]
-	}
-	utility := make([]float64, len(traversal.Ranges[traversal.Traverser]))
-	node.winnerShowdownProbabilityCalculation(traversal, utility, opponentReachProb, TraverserRanks, OpponentRanks)
-	node.loserShowdownProbabilityCalculation(traversal, utility, opponentReachProb, TraverserRanks, OpponentRanks)
-	return utility
+	return node.GetUtil(traversal, opponentReachProb)
 }
\ No newline at end of file
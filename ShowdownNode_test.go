@@ -1,4 +1,10 @@
 package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
 /*
 import (
 	"github.com/stretchr/testify/assert"
@@ -23,3 +29,20 @@ func TestShowdownNode_GetUtil(t *testing.T) {
 	traversal.Traverser = 0
 }
 */
+
+func TestShowdownNodeMatchesSlowShowdown(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c"), poker.NewCard("Ts")}
+	oop := RemoveConflicts(HandsStringToHandRange("AA, KQ, 99, 87s, 65s, /50.0/JTs"), board)
+	ip := RemoveConflicts(HandsStringToHandRange("KK, AK, T9s, 44, 32s, /25.0/JJ"), board)
+	cache := NewRiverEvaluationCache(oop, ip)
+	node := NewShowdownNode(100, 1, board, cache)
+	node.cacheIndex = cache.InsertBoard(board)
+	traversal := NewTraversal(oop, ip)
+	for traverser := range traversal.Ranges {
+		traversal.Traverser = traverser
+		opponentReach := convertRangeToFloatSlice(traversal.Ranges[traverser^1])
+		ranks := cache.RankingCache[node.cacheIndex]
+		slow := node.ShowdownSlow(traversal, ranks[traverser], ranks[traverser^1], opponentReach)
+		assert.InDeltaSlice(t, slow, node.GetUtil(traversal, opponentReach), 1e-9)
+	}
+}
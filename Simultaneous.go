@@ -0,0 +1,89 @@
+package solv
+
+import "math"
+
+//SetSimultaneous sets if every iteration walks the tree once and updates the regrets of both players, instead of
+//walking it once for each player with the walk of the ip player already seeing the oop updates. A simultaneous
+//walk evaluates the showdowns of both players at once and shares the rest of the walk, so an iteration takes less
+//time, but alternating updates usually need fewer iterations to reach the same exploitability. Actions are not
+//pruned in simultaneous walks. This is disabled by default
+func (traversal *Traversal) SetSimultaneous(enabled bool) {
+	traversal.simultaneous = enabled
+}
+
+//iterate runs one iteration of the solve from root, oop and ip are the reach probabilities of the ranges
+func (traversal *Traversal) iterate(root *GameNode, oop, ip []float64) {
+	if traversal.simultaneous {
+		//the oop player takes the place of the traverser so every node passes the reach in the usual order
+		traversal.Traverser = 0
+		root.CFRTraversal(traversal, oop, ip)
+		return
+	}
+	traversal.Traverser = 0
+	root.CFRTraversal(traversal, oop, ip)
+	traversal.Traverser = 1
+	root.CFRTraversal(traversal, ip, oop)
+}
+
+//utilitySize returns the length of the utilities CFRTraversal returns, the number of traverser hands or for a
+//simultaneous walk the number of hands of both players, with the oop hands before the ip hands
+func (traversal *Traversal) utilitySize() int {
+	if traversal.simultaneous {
+		return len(traversal.Ranges[0]) + len(traversal.Ranges[1])
+	}
+	return len(traversal.Ranges[traversal.Traverser])
+}
+
+//playerUtility returns the part of the utilities of a simultaneous walk holding the hands of player
+func (traversal *Traversal) playerUtility(utility []float64, player int) []float64 {
+	if player == 0 {
+		return utility[:len(traversal.Ranges[0])]
+	}
+	return utility[len(traversal.Ranges[0]):]
+}
+
+//unpermuteUtilityInto maps the utilities of the canonical subtree back to the hands of an isomorphic card, for both
+//players in a simultaneous walk
+func (traversal *Traversal) unpermuteUtilityInto(mapped, result []float64, isomorphism *cardIsomorphism,
+	bestResponse bool) {
+	if !traversal.simultaneous || bestResponse {
+		unpermuteResultInto(mapped, result, isomorphism.handPermutations[traversal.Traverser])
+		return
+	}
+	for player := range traversal.Ranges {
+		unpermuteResultInto(traversal.playerUtility(mapped, player), traversal.playerUtility(result, player),
+			isomorphism.handPermutations[player])
+	}
+}
+
+//simultaneousCFR traverses the children of the node with the reach of the acting player split by its strategy.
+//The acting player gets the strategy weighted utility of the actions and updates its regrets, the other player
+//gets the sum of the utilities of the actions like at an opponent node
+func (node *GameNode) simultaneousCFR(traversal *Traversal, level *scratchLevel, oopReachProb, ipReachProb,
+	nodeUtility []float64) {
+	player := node.playerNode
+	reach := oopReachProb
+	if player == 1 {
+		reach = ipReachProb
+	}
+	hands := traversal.activeHands(player)
+	storedUtility := level.actionUtilities(node.numActions, len(nodeUtility))
+	nextReachProbs := level.actionReaches(node.numActions, len(reach))
+	for i := 0; i < node.numActions; i++ {
+		node.values.actionReach(hands, nextReachProbs[i], reach, i)
+	}
+	node.traverseActions(traversal, level, oopReachProb, ipReachProb, nextReachProbs, nil, storedUtility)
+
+	actingUtility := traversal.playerUtility(nodeUtility, player)
+	otherUtility := traversal.playerUtility(nodeUtility, player^1)
+	actionUtility := level.actionViews(node.numActions)
+	for i := 0; i < node.numActions; i++ {
+		actionUtility[i] = traversal.playerUtility(storedUtility[i], player)
+		node.values.addWeightedUtility(hands, actingUtility, actionUtility[i], i)
+		otherActionUtility := traversal.playerUtility(storedUtility[i], player^1)
+		for _, hand := range traversal.activeHands(player ^ 1) {
+			otherUtility[hand] += otherActionUtility[hand]
+		}
+	}
+	node.updateValues(traversal, hands, reach, actingUtility, actionUtility, math.Inf(-1))
+}
@@ -0,0 +1,109 @@
+package solv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+//assertSamePlayerValues checks the regrets and strategy sums of the game nodes of player are the same in two trees
+func assertSamePlayerValues(t *testing.T, expected, actual Node, player int) {
+	switch node := expected.(type) {
+	case *GameNode:
+		other := actual.(*GameNode)
+		if node.playerNode == player {
+			for hand := 0; hand < node.NumHands(); hand++ {
+				assert.InDeltaSlice(t, node.Regrets(hand), other.Regrets(hand), 1e-9)
+				assert.InDeltaSlice(t, node.StrategySums(hand), other.StrategySums(hand), 1e-9)
+			}
+		}
+		for index := range node.nextNodes {
+			assertSamePlayerValues(t, node.nextNodes[index], other.nextNodes[index], player)
+		}
+	case *ChanceNode:
+		other := actual.(*ChanceNode)
+		for index := range node.nextNodes {
+			assertSamePlayerValues(t, node.nextNodes[index], other.nextNodes[index], player)
+		}
+	}
+}
+
+//copyPlayerValues copies the regrets, strategies and strategy sums of the game nodes of player between two trees
+func copyPlayerValues(from, to Node, player int) {
+	switch node := from.(type) {
+	case *GameNode:
+		other := to.(*GameNode)
+		if node.playerNode == player {
+			copy(other.values.regrets, node.values.regrets)
+			copy(other.values.strategies, node.values.strategies)
+			copy(other.values.strategySums, node.values.strategySums)
+		}
+		for index := range node.nextNodes {
+			copyPlayerValues(node.nextNodes[index], other.nextNodes[index], player)
+		}
+	case *ChanceNode:
+		other := to.(*ChanceNode)
+		for index := range node.nextNodes {
+			copyPlayerValues(node.nextNodes[index], other.nextNodes[index], player)
+		}
+	}
+}
+
+func TestSimultaneousIterationUpdatesBothPlayers(t *testing.T) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	oop := convertRangeToFloatSlice(traversal.Ranges[0])
+	ip := convertRangeToFloatSlice(traversal.Ranges[1])
+	traversal.SetSimultaneous(true)
+
+	//both players see the strategies from before the iteration, so each player is updated like by the walk of an
+	//alternating iteration in which it traverses first. The trees of the two walks swap their updates afterwards
+	oopRoot, oopTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	ipRoot, ipTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	oopTrav.Traverser = 0
+	ipTrav.Traverser = 1
+	for iteration := 0; iteration < 5; iteration++ {
+		traversal.Iteration = iteration
+		traversal.iterate(root, oop, ip)
+		oopTrav.Iteration = iteration
+		oopRoot.CFRTraversal(oopTrav, oop, ip)
+		ipTrav.Iteration = iteration
+		ipRoot.CFRTraversal(ipTrav, ip, oop)
+		copyPlayerValues(oopRoot, ipRoot, 0)
+		copyPlayerValues(ipRoot, oopRoot, 1)
+	}
+	assertSamePlayerValues(t, oopRoot, root, 0)
+	assertSamePlayerValues(t, ipRoot, root, 1)
+}
+
+func TestSimultaneousConverges(t *testing.T) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	traversal.SetSimultaneous(true)
+	oop := convertRangeToFloatSlice(traversal.Ranges[0])
+	ip := convertRangeToFloatSlice(traversal.Ranges[1])
+	for iteration := 0; iteration < 200; iteration++ {
+		traversal.Iteration = iteration
+		traversal.iterate(root, oop, ip)
+	}
+	_, _, exploitability := Exploitability(traversal, root)
+
+	//the second walk of an alternating iteration already plays against the updated strategy of the first, so the
+	//alternating solve of the same tree is more than ten times less exploitable, but not fifty
+	alternatingRoot, alternatingTraversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	runIterations(alternatingTraversal, 200, alternatingRoot)
+	_, _, alternatingExploitability := Exploitability(alternatingTraversal, alternatingRoot)
+	assert.Greater(t, exploitability, 10*alternatingExploitability)
+	assert.Less(t, exploitability, 50*alternatingExploitability)
+}
+
+//compare with BenchmarkCFRIterationTurn, which runs the two walks of an alternating iteration
+func BenchmarkCFRIterationTurnSimultaneous(b *testing.B) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	traversal.SetSimultaneous(true)
+	ip := convertRangeToFloatSlice(traversal.Ranges[1])
+	oop := convertRangeToFloatSlice(traversal.Ranges[0])
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		traversal.Iteration++
+		traversal.iterate(root, oop, ip)
+	}
+}
@@ -0,0 +1,163 @@
+package solv
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+//nodeSolution is the trained state of a single GameNode as written by SaveSolution. Strategies is only stored
+//for locked nodes since it is recomputed from the regrets everywhere else
+type nodeSolution struct {
+	Regrets      [][]float64
+	StrategySums [][]float64
+	Locked          bool
+	Strategies      [][]float64
+	LockFrequencies []float64
+}
+
+//solution holds the saved game nodes in the order of walkGameNodes. Splits holds the isomorphic cards with a
+//subtree of their own of every chance node in the order of walkChanceNodes, they change the game nodes of the tree
+type solution struct {
+	Nodes  []nodeSolution
+	Splits [][]int
+}
+
+//SaveSolution writes the regrets, strategy sums and locks of every GameNode in the tree to w
+func SaveSolution(w io.Writer, root *GameNode) error {
+	var sol solution
+	walkChanceNodes(root, func(node *ChanceNode) error {
+		sol.Splits = append(sol.Splits, node.splitCards())
+		return nil
+	})
+	walkGameNodes(root, func(node *GameNode) {
+		saved := nodeSolution{
+			Regrets:         handMatrix(node, node.Regrets),
+			StrategySums:    handMatrix(node, node.StrategySums),
+			Locked:          node.locked,
+			LockFrequencies: node.lockFrequencies,
+		}
+		if node.locked {
+			saved.Strategies = handMatrix(node, node.Strategy)
+		}
+		sol.Nodes = append(sol.Nodes, saved)
+	})
+	return gob.NewEncoder(w).Encode(&sol)
+}
+
+//LoadSolution reads a solution written by SaveSolution into a tree constructed with the same parameters and
+//ranges as the saved one, an error is returned if the shape of the tree does not match
+func LoadSolution(r io.Reader, root *GameNode) error {
+	var sol solution
+	if err := gob.NewDecoder(r).Decode(&sol); err != nil {
+		return err
+	}
+	//the isomorphic cards that had their own subtree in the saved tree are given one before the nodes are matched
+	chanceNodes := 0
+	err := walkChanceNodes(root, func(node *ChanceNode) error {
+		if chanceNodes < len(sol.Splits) {
+			if err := node.splitCardsAt(sol.Splits[chanceNodes]); err != nil {
+				return err
+			}
+		}
+		chanceNodes++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	nodes := make([]*GameNode, 0, len(sol.Nodes))
+	walkGameNodes(root, func(node *GameNode) {
+		nodes = append(nodes, node)
+	})
+	if len(nodes) != len(sol.Nodes) {
+		return fmt.Errorf("solution has %v nodes, tree has %v", len(sol.Nodes), len(nodes))
+	}
+	for index, node := range nodes {
+		saved := sol.Nodes[index]
+		if len(saved.Regrets) != node.NumHands() ||
+			(len(saved.Regrets) > 0 && len(saved.Regrets[0]) != node.numActions) {
+			return fmt.Errorf("node %v does not match the shape of the saved solution", index)
+		}
+	}
+	for index, node := range nodes {
+		saved := sol.Nodes[index]
+		for hand := 0; hand < node.NumHands(); hand++ {
+			for action := 0; action < node.numActions; action++ {
+				node.values.setRegret(hand, action, saved.Regrets[hand][action])
+				node.values.setStrategySum(hand, action, saved.StrategySums[hand][action])
+				if saved.Locked {
+					node.values.setStrategy(hand, action, saved.Strategies[hand][action])
+				}
+			}
+		}
+		node.locked = saved.Locked
+		node.lockFrequencies = saved.LockFrequencies
+	}
+	return nil
+}
+
+//handMatrix returns the values of every hand of the node, the solution format keeps one slice per hand so it
+//does not depend on the precision of the tree
+func handMatrix(node *GameNode, values func(hand int) []float64) [][]float64 {
+	matrix := make([][]float64, node.NumHands())
+	for hand := range matrix {
+		matrix[hand] = values(hand)
+	}
+	return matrix
+}
+
+//walkGameNodes calls visit on every GameNode of the tree in a fixed depth first order, subtrees shared by
+//isomorphic cards are visited once and the game nodes sharing the strategies of their bucket are skipped
+func walkGameNodes(root *GameNode, visit func(node *GameNode)) {
+	walkStreetGameNodes(root, visit, false)
+}
+
+//walkStreetGameNodes is walkGameNodes not visiting the game nodes of the street of root if they are shared
+func walkStreetGameNodes(root *GameNode, visit func(node *GameNode), shared bool) {
+	if !shared {
+		visit(root)
+	}
+	for _, next := range root.nextNodes {
+		switch node := next.(type) {
+		case *GameNode:
+			walkStreetGameNodes(node, visit, shared)
+		case *ChanceNode:
+			for index, chanceNext := range node.nextNodes {
+				if node.sharesCanonical(index) {
+					continue
+				}
+				if gameNode, ok := chanceNext.(*GameNode); ok {
+					walkStreetGameNodes(gameNode, visit, node.sharesValues(index))
+				}
+			}
+		}
+	}
+}
+
+//walkChanceNodes calls visit on every ChanceNode of the tree in a fixed depth first order, a chance node is visited
+//before the subtrees of its cards and subtrees shared by isomorphic cards are visited once. It stops at the first
+//error returned by visit
+func walkChanceNodes(root Node, visit func(node *ChanceNode) error) error {
+	switch node := root.(type) {
+	case *GameNode:
+		for _, next := range node.nextNodes {
+			if err := walkChanceNodes(next, visit); err != nil {
+				return err
+			}
+		}
+	case *ChanceNode:
+		if err := visit(node); err != nil {
+			return err
+		}
+		for index, next := range node.nextNodes {
+			if node.sharesCanonical(index) {
+				continue
+			}
+			if err := walkChanceNodes(next, visit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
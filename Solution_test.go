@@ -0,0 +1,42 @@
+package solv
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSaveAndLoadSolution(t *testing.T) {
+	root, trav := newRiverTestTree(NewConstructionParams(1.0, 1.2))
+	checked := root.GetNext(0).(*GameNode)
+	assert.Nil(t, checked.LockRangeStrategy([]float64{1, 2}))
+	assert.Nil(t, root.LockAggregateFrequencies([]float64{0.5, 0.5}))
+	runIterations(trav, 30, root)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, SaveSolution(&buffer, root))
+
+	loaded, _ := newRiverTestTree(NewConstructionParams(1.0, 1.2))
+	assert.Nil(t, LoadSolution(bytes.NewReader(buffer.Bytes()), loaded))
+
+	expected := make([]*GameNode, 0)
+	walkGameNodes(root, func(node *GameNode) {
+		expected = append(expected, node)
+	})
+	index := 0
+	walkGameNodes(loaded, func(node *GameNode) {
+		assert.Equal(t, expected[index].locked, node.locked)
+		assert.Equal(t, expected[index].lockFrequencies, node.lockFrequencies)
+		for hand := 0; hand < node.NumHands(); hand++ {
+			assert.Equal(t, expected[index].Regrets(hand), node.Regrets(hand))
+			assert.Equal(t, expected[index].StrategySums(hand), node.StrategySums(hand))
+		}
+		index++
+	})
+	assert.True(t, loaded.GetNext(0).(*GameNode).IsLocked())
+	assert.Equal(t, checked.Strategy(0), loaded.GetNext(0).(*GameNode).Strategy(0))
+
+	turnBoard := riverBoard[:4]
+	other := mustConstructTree(400, 4000, NewConstructionParams(1.0, 1.2), trav.Ranges[1], trav.Ranges[0], turnBoard)
+	assert.NotNil(t, LoadSolution(bytes.NewReader(buffer.Bytes()), other))
+}
@@ -0,0 +1,260 @@
+package solv
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+//StrategyOverrides maps a GameNode to the strategy a player actually uses there, strategy[hand][action] gives the
+//frequency of each action for the hand with the same index in the acting player's range
+type StrategyOverrides map[*GameNode][][]float64
+
+//HandLoss is the ev a single hand gives up at a node compared to its best action
+type HandLoss struct {
+	Hand Hand
+	Loss float64
+}
+
+//NodeLoss gives the ev lost at a single node of the evaluated player, Hands is sorted with the largest loss first
+//and only contains hands that lose ev. Losses are weighted by the probability of reaching the node and are on
+//the same scale as the evs of the StrategyReport
+type NodeLoss struct {
+	Path  []int
+	Node  *GameNode
+	Loss  float64
+	Hands []HandLoss
+}
+
+//StrategyReport compares a player's strategy with the solver's average strategy, both evaluated against an
+//opponent playing a best response. NodeLosses is sorted with the most costly node first
+type StrategyReport struct {
+	Player     int
+	EV         float64
+	SolverEV   float64
+	EVLoss     float64
+	NodeLosses []NodeLoss
+}
+
+//strategyProfile replaces the average strategies used by BestResponse so strategies that were not found by the
+//solver can be evaluated. Responses holds the pure best response of the opponent once it has been recorded
+type strategyProfile struct {
+	overrides  StrategyOverrides
+	responses  map[*GameNode][]int
+	losses     map[*GameNode][]float64
+	recording  bool
+	evaluating bool
+	mutex      sync.RWMutex
+}
+
+func (profile *strategyProfile) strategy(node *GameNode, hand int) []float64 {
+	profile.mutex.RLock()
+	actions, ok := profile.responses[node]
+	profile.mutex.RUnlock()
+	if ok {
+		strategy := make([]float64, node.numActions)
+		strategy[actions[hand]] = 1.0
+		return strategy
+	}
+	if strategy, ok := profile.overrides[node]; ok {
+		return strategy[hand]
+	}
+	return node.getAverageStrategy(hand)
+}
+
+//recordResponse stores the best response actions at a node. Nodes the evaluated player never reaches have no
+//meaningful best response, the opponent keeps the solver's average strategy there
+func (profile *strategyProfile) recordResponse(node *GameNode, actions []int, evaluatedReachProb []float64) {
+	if !profile.recording {
+		return
+	}
+	reached := false
+	for hand := range evaluatedReachProb {
+		reached = reached || evaluatedReachProb[hand] > 0
+	}
+	if !reached {
+		return
+	}
+	profile.mutex.Lock()
+	profile.responses[node] = actions
+	profile.mutex.Unlock()
+}
+
+func (profile *strategyProfile) recordLosses(node *GameNode, losses []float64) {
+	profile.mutex.Lock()
+	profile.losses[node] = losses
+	profile.mutex.Unlock()
+}
+
+//EvaluateStrategy evaluates the strategy of player against a best responding opponent. Nodes of the player that
+//are not in overrides use the solver's average strategy, so an empty map evaluates the solver itself. Subtrees
+//shared by isomorphic cards hold a single best response, so in such trees the overrides should be symmetric
+//under the suit permutations of the board for the per node losses to be exact
+func EvaluateStrategy(traversal *Traversal, root *GameNode, player int, overrides StrategyOverrides) (*StrategyReport, error) {
+	normalized, err := normalizeOverrides(player, overrides)
+	if err != nil {
+		return nil, err
+	}
+	opponent := player ^ 1
+	defer func(traverser int) {
+		traversal.Traverser = traverser
+		traversal.profile = nil
+	}(traversal.Traverser)
+
+	playerRelativeProb := RangeRelativeProbabilities(traversal.Ranges[player], traversal.Ranges[opponent])
+	opponentRelativeProb := RangeRelativeProbabilities(traversal.Ranges[opponent], traversal.Ranges[player])
+
+	//with rake the opponent's gain is not the player's loss, so both evs are evaluated against the recorded response
+	evaluate := func(overrides StrategyOverrides) (*strategyProfile, float64) {
+		profile := &strategyProfile{
+			overrides: overrides,
+			responses: make(map[*GameNode][]int),
+			losses:    make(map[*GameNode][]float64),
+			recording: true,
+		}
+		traversal.Traverser = opponent
+		traversal.profile = profile
+		root.OverallBestResponse(traversal, opponentRelativeProb)
+
+		profile.recording = false
+		profile.evaluating = true
+		traversal.Traverser = player
+		return profile, root.OverallBestResponse(traversal, playerRelativeProb)
+	}
+	_, solverEV := evaluate(nil)
+	profile, ev := evaluate(normalized)
+
+	report := &StrategyReport{
+		Player:   player,
+		EV:       ev,
+		SolverEV: solverEV,
+		EVLoss:   solverEV - ev,
+	}
+	report.NodeLosses = profile.nodeLosses(traversal, root, player)
+	return report, nil
+}
+
+func normalizeOverrides(player int, overrides StrategyOverrides) (StrategyOverrides, error) {
+	normalized := make(StrategyOverrides, len(overrides))
+	for node, strategy := range overrides {
+		if node.playerNode != player {
+			return nil, fmt.Errorf("override for a node of player %v, evaluating player %v", node.playerNode, player)
+		}
+		if len(strategy) != node.NumHands() {
+			return nil, fmt.Errorf("override has %v hands, node has %v", len(strategy), node.NumHands())
+		}
+		normalized[node] = make([][]float64, len(strategy))
+		for hand := range strategy {
+			if err := node.checkFrequencies(strategy[hand]); err != nil {
+				return nil, fmt.Errorf("hand %v: %v", hand, err)
+			}
+			sum := 0.0
+			for _, frequency := range strategy[hand] {
+				sum += frequency
+			}
+			normalized[node][hand] = make([]float64, node.numActions)
+			for i := range strategy[hand] {
+				normalized[node][hand][i] = strategy[hand][i] / sum
+			}
+		}
+	}
+	return normalized, nil
+}
+
+//nodeLosses weights the counterfactual losses recorded during the evaluation by the player's own probability of
+//reaching each node, normalized the same way as OverallBestResponse
+func (profile *strategyProfile) nodeLosses(traversal *Traversal, root *GameNode, player int) []NodeLoss {
+	playerRange := traversal.Ranges[player]
+	unblocked := UnblockedHands(playerRange, traversal.Ranges[player^1])
+	normalizingValue := 0.0
+	for hand := range playerRange {
+		normalizingValue += playerRange[hand].Combos * unblocked[hand]
+	}
+
+	nodeLosses := make([]NodeLoss, 0, len(profile.losses))
+	reach := convertRangeToFloatSlice(playerRange)
+	hands := make([]Hand, len(playerRange))
+	for hand := range playerRange {
+		hands[hand] = playerRange[hand].Hand
+	}
+	profile.walkPlayerReach(traversal, root, player, reach, hands, []int{},
+		func(node *GameNode, path []int, reach []float64, hands []Hand) {
+			losses, ok := profile.losses[node]
+			if !ok {
+				return
+			}
+			nodeLoss := NodeLoss{Path: path, Node: node}
+			for hand := range losses {
+				loss := losses[hand] * reach[hand] / normalizingValue
+				if loss > 0 {
+					nodeLoss.Loss += loss
+					nodeLoss.Hands = append(nodeLoss.Hands, HandLoss{Hand: hands[hand], Loss: loss})
+				}
+			}
+			sort.Slice(nodeLoss.Hands, func(i, j int) bool {
+				return nodeLoss.Hands[i].Loss > nodeLoss.Hands[j].Loss
+			})
+			nodeLosses = append(nodeLosses, nodeLoss)
+		})
+	sort.SliceStable(nodeLosses, func(i, j int) bool {
+		return nodeLosses[i].Loss > nodeLosses[j].Loss
+	})
+	return nodeLosses
+}
+
+//walkPlayerReach visits every node of player with the player's own reach probabilities under the profile. Below
+//an isomorphic card the reach is permuted to the hands of the shared subtree and hands gives the hand actually
+//held for each index
+func (profile *strategyProfile) walkPlayerReach(traversal *Traversal, current Node, player int, reach []float64,
+	hands []Hand, path []int, visit func(node *GameNode, path []int, reach []float64, hands []Hand)) {
+	switch node := current.(type) {
+	case *GameNode:
+		if node.playerNode == player {
+			visit(node, path, reach, hands)
+		}
+		for i, next := range node.nextNodes {
+			nextReach := reach
+			if node.playerNode == player {
+				nextReach = make([]float64, len(reach))
+				for hand := range reach {
+					nextReach[hand] = reach[hand] * profile.strategy(node, hand)[i]
+				}
+			}
+			profile.walkPlayerReach(traversal, next, player, nextReach, hands, appendPath(path, i), visit)
+		}
+	case *ChanceNode:
+		for i, next := range node.nextNodes {
+			nextReach := removeCardReach(traversal.Ranges[player], reach, node.nextCards[i])
+			nextHands := hands
+			if isomorphism := node.isomorphicCard(i); isomorphism != nil {
+				handPermutation := isomorphism.handPermutations[player]
+				nextReach = permuteReach(nextReach, handPermutation)
+				nextHands = make([]Hand, len(hands))
+				for hand := range hands {
+					nextHands[handPermutation[hand]] = hands[hand]
+				}
+			}
+			profile.walkPlayerReach(traversal, next, player, nextReach, nextHands, appendPath(path, i), visit)
+		}
+	}
+}
+
+func appendPath(path []int, index int) []int {
+	next := make([]int, len(path)+1)
+	copy(next, path)
+	next[len(path)] = index
+	return next
+}
+
+//strategyValue returns the ev of player when both players play the average strategies of the tree, normalized like
+//OverallBestResponse. The values of both players sum to zero unless the tree takes rake
+func strategyValue(traversal *Traversal, root *GameNode, player int) float64 {
+	defer func(traverser int, profile *strategyProfile) {
+		traversal.Traverser = traverser
+		traversal.profile = profile
+	}(traversal.Traverser, traversal.profile)
+	traversal.Traverser = player
+	traversal.profile = &strategyProfile{losses: make(map[*GameNode][]float64), evaluating: true}
+	return root.OverallBestResponse(traversal, RangeRelativeProbabilities(traversal.Ranges[player],
+		traversal.Ranges[player^1]))
+}
@@ -0,0 +1,42 @@
+package solv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEvaluateStrategy(t *testing.T) {
+	root, trav := newRiverTestTree(NewConstructionParams(1.0, 1.2))
+	runIterations(trav, 300, root)
+
+	solver, err := EvaluateStrategy(trav, root, 0, StrategyOverrides{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 0, solver.EVLoss, 0.0001)
+	assert.InDelta(t, solver.SolverEV, solver.EV, 0.0001)
+
+	//oop always bets, the solver checks its whole range
+	bets := make([][]float64, len(trav.Ranges[0]))
+	for hand := range bets {
+		bets[hand] = []float64{0, 1}
+	}
+	report, err := EvaluateStrategy(trav, root, 0, StrategyOverrides{root: bets})
+	assert.Nil(t, err)
+	assert.InDelta(t, solver.SolverEV, report.SolverEV, 0.0001)
+	assert.True(t, report.EVLoss > 1)
+	assert.InDelta(t, report.SolverEV-report.EVLoss, report.EV, 0.0001)
+	for i := 1; i < len(report.NodeLosses); i++ {
+		assert.True(t, report.NodeLosses[i-1].Loss >= report.NodeLosses[i].Loss)
+	}
+	for _, nodeLoss := range report.NodeLosses {
+		if nodeLoss.Node == root {
+			assert.Equal(t, []int{}, nodeLoss.Path)
+			assert.True(t, nodeLoss.Loss > 0)
+			for _, handLoss := range nodeLoss.Hands {
+				assert.True(t, handLoss.Loss <= nodeLoss.Hands[0].Loss)
+			}
+		}
+	}
+
+	_, err = EvaluateStrategy(trav, root, 1, StrategyOverrides{root: bets})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,134 @@
+package solv
+
+import (
+	"errors"
+	"github.com/chehsunliu/poker"
+	"math"
+)
+
+//SubgameSpot is the state of the game at the start of a street somewhere in a solved tree. The ranges are
+//the starting ranges weighted by the probability of each hand taking the line leading to the spot, so
+//they can be passed straight back into ConstructTree and NewTraversal for a more detailed solve
+type SubgameSpot struct {
+	Board          []poker.Card
+	PotSize        float64
+	EffectiveStack float64
+	Ranges         [2]Range
+}
+
+//NewSubgameSpot follows path (see NodeAtPath) through a solved tree and returns the spot it ends at. The
+//path must end directly after a card is dealt by a ChanceNode, or be empty to describe the root itself.
+//Card indexes after an isomorphic card refer to the shared subtree of its canonical card, the spot is mapped back
+//to the suits of the cards actually dealt
+func NewSubgameSpot(root *GameNode, traversal *Traversal, board []poker.Card, path []int) (*SubgameSpot, error) {
+	line, err := followLine(root, traversal, board, path)
+	if err != nil {
+		return nil, err
+	}
+	spot, _ := line.spot(traversal)
+	return spot, nil
+}
+
+//subgameLine is where a path through a solved tree ends: the game node starting the street, the board, the reach
+//probabilities of every hand of both ranges of the traversal and the hand of the stored tree playing in place of
+//each hand, since subtrees of isomorphic cards are stored for the canonical card
+type subgameLine struct {
+	start        *GameNode
+	board        []poker.Card
+	reach        [2][]float64
+	handToStored [2][]int
+}
+
+func followLine(root *GameNode, traversal *Traversal, board []poker.Card, path []int) (*subgameLine, error) {
+	reach := [2][]float64{
+		convertRangeToFloatSlice(traversal.Ranges[0]),
+		convertRangeToFloatSlice(traversal.Ranges[1]),
+	}
+	currentBoard := make([]poker.Card, len(board))
+	copy(currentBoard, board)
+
+	//subtrees of isomorphic cards are stored for the canonical card, toStored maps the cards of the line to the
+	//cards of the stored tree and handToStored the hands of each range in the same way
+	toStored := identityPermutation
+	var handToStored [2][]int
+	for player := range handToStored {
+		handToStored[player] = make([]int, len(reach[player]))
+		for hand := range handToStored[player] {
+			handToStored[player][hand] = hand
+		}
+	}
+
+	var current Node = root
+	streetStart := true
+	for depth, index := range path {
+		next, err := nextOnPath(current, index, depth)
+		if err != nil {
+			return nil, err
+		}
+		switch node := current.(type) {
+		case *GameNode:
+			playerReach := reach[node.playerNode]
+			for hand := range playerReach {
+				playerReach[hand] *= node.getAverageStrategy(handToStored[node.playerNode][hand])[index]
+			}
+			streetStart = false
+		case *ChanceNode:
+			card := permuteCard(node.nextCards[index], toStored.inverse())
+			for player := range reach {
+				for hand, combo := range traversal.Ranges[player] {
+					if combo.Hand[0] == card || combo.Hand[1] == card {
+						reach[player][hand] = 0
+					}
+				}
+			}
+			if isomorphism := node.isomorphicCard(index); isomorphism != nil {
+				toStored = toStored.then(isomorphism.permutation)
+				for player := range handToStored {
+					for hand := range handToStored[player] {
+						handToStored[player][hand] = isomorphism.handPermutations[player][handToStored[player][hand]]
+					}
+				}
+			}
+			currentBoard = append(currentBoard, card)
+			streetStart = true
+		}
+		current = next
+	}
+
+	start, ok := current.(*GameNode)
+	if !ok || !streetStart {
+		return nil, errors.New("path must end at the start of a street")
+	}
+	return &subgameLine{start: start, board: currentBoard, reach: reach, handToStored: handToStored}, nil
+}
+
+//spot returns the spot at the end of the line, with the index in the range of the traversal of every hand of the
+//ranges of the spot
+func (line *subgameLine) spot(traversal *Traversal) (*SubgameSpot, [2][]int) {
+	spot := SubgameSpot{
+		Board:          line.board,
+		PotSize:        line.start.potSize,
+		EffectiveStack: math.Min(line.start.ipPlayerStack, line.start.oopPlayerStack),
+	}
+	var indexes [2][]int
+	for player := range line.reach {
+		spot.Ranges[player] = make(Range, 0, len(line.reach[player]))
+		for hand, combo := range traversal.Ranges[player] {
+			if line.reach[player][hand] > 0 && !CheckHandBoardOverlap(combo.Hand, line.board) {
+				spot.Ranges[player] = append(spot.Ranges[player], *NewCombo(combo.Hand, line.reach[player][hand]))
+				indexes[player] = append(indexes[player], hand)
+			}
+		}
+	}
+	return &spot, indexes
+}
+
+//ConstructTree builds a new game tree for the spot using params, see ConstructTree
+func (spot *SubgameSpot) ConstructTree(params *ConstructionParams) (*GameNode, error) {
+	return ConstructTree(spot.PotSize, spot.EffectiveStack, params, spot.Ranges[1], spot.Ranges[0], spot.Board)
+}
+
+//NewTraversal returns a traversal over the narrowed ranges of the spot
+func (spot *SubgameSpot) NewTraversal() *Traversal {
+	return NewTraversal(spot.Ranges[0], spot.Ranges[1])
+}
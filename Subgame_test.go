@@ -0,0 +1,49 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewSubgameSpot(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Ac"), poker.NewCard("7s"), poker.NewCard("5s"), poker.NewCard("3d")}
+	oop := RemoveConflicts(HandsStringToHandRange("JJ"), board)
+	ip := RemoveConflicts(HandsStringToHandRange("QQ, T9"), board)
+	root := mustConstructTree(400, 4000, NewConstructionParams(1.0, 1.2), ip, oop, board)
+	trav := NewTraversal(oop, ip)
+
+	checkBack := root.GetNext(0).(*GameNode).GetNext(0).(*ChanceNode)
+	river := poker.NewCard("Jc")
+	cardIndex := checkBack.CardIndex(river)
+	assert.True(t, cardIndex >= 0)
+
+	spot, err := NewSubgameSpot(root, trav, board, []int{0, 0, cardIndex})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(spot.Board))
+	assert.Equal(t, river, spot.Board[4])
+	assert.Equal(t, 400.0, spot.PotSize)
+	assert.Equal(t, 4000.0, spot.EffectiveStack)
+
+	//nothing is trained so every action is taken with equal probability
+	oopWeight := 1.0 / float64(root.NumActions())
+	ipWeight := 1.0 / float64(root.GetNext(0).(*GameNode).NumActions())
+	assert.Equal(t, len(oop)-3, len(spot.Ranges[0]))
+	for _, combo := range spot.Ranges[0] {
+		assert.False(t, CheckHandBoardOverlap(combo.Hand, spot.Board))
+		assert.InDelta(t, oopWeight, combo.Combos, 0.0001)
+	}
+	assert.Equal(t, len(ip), len(spot.Ranges[1]))
+	for _, combo := range spot.Ranges[1] {
+		assert.InDelta(t, ipWeight, combo.Combos, 0.0001)
+	}
+
+	_, err = NewSubgameSpot(root, trav, board, []int{0})
+	assert.NotNil(t, err)
+	_, err = NewSubgameSpot(root, trav, board, []int{0, 7})
+	assert.NotNil(t, err)
+
+	subgame, err := spot.ConstructTree(NewConstructionParams(0.5, 1.2))
+	assert.Nil(t, err)
+	assert.Equal(t, 400.0, subgame.PotSize())
+}
@@ -10,6 +10,8 @@ import (
 type TerminalNode struct {
 	*GameNode
 	winUtility float64
+	rakeUtility float64
+	icm *[2]payoff
 	board []poker.Card
 }
 
@@ -21,60 +23,93 @@ func NewTerminalNode(gameNode *GameNode) *TerminalNode {
 	return &node
 }
 
+//setRake takes rake from the pot the player who did not fold wins
+func (node *TerminalNode) setRake(rake float64) {
+	node.winUtility, node.rakeUtility = rakeUtilities(node.potSize, rake)
+}
+
 func (node *TerminalNode) IsTerminal() bool {
 	return node.isTerminal
 }
 
 func (node *TerminalNode) CFRTraversal(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
-	return node.GetUtil(traversal, traverserReachProb, opponentReachProb)
+	utilities := traversal.level().utility(traversal.utilitySize())
+	if traversal.simultaneous {
+		reach := [2][]float64{traverserReachProb, opponentReachProb}
+		for player := range reach {
+			node.foldUtil(traversal, player, traversal.playerUtility(utilities, player), reach[player^1],
+				node.playerWinUtility(traversal, player))
+		}
+		return utilities
+	}
+	node.foldUtil(traversal, traversal.Traverser, utilities, opponentReachProb, node.traverserWinUtility(traversal))
+	return utilities
+}
+
+//traverserWinUtility returns the utility of the traverser, positive if the opponent folded
+func (node *TerminalNode) traverserWinUtility(traversal *Traversal) float64 {
+	return node.playerWinUtility(traversal, traversal.Traverser)
+}
+
+func (node *TerminalNode) playerWinUtility(traversal *Traversal, player int) float64 {
+	payoff := nodePayoff(traversal, node.icm, player, node.winUtility, node.rakeUtility)
+	if player == node.playerNode {
+		return payoff.win
+	}
+	return payoff.lose
 }
 
 //GetUtil accepts the if the current traverser is IP, the reach probabilities for each player and then returns
 //a map of hand to utility
 func (node *TerminalNode) GetUtil(traversal *Traversal, traverserReachProb, opponentReachProb []float64) []float64 {
-	var result []float64
-	//if the traverser is the same as the terminal node that means they are +ev,
-	if traversal.Traverser == node.playerNode {
-		result =  node.TraverserUtil(traversal, traverserReachProb, opponentReachProb, node.winUtility)
-	} else {
-		result =  node.TraverserUtil(traversal, traverserReachProb, opponentReachProb, -node.winUtility)
-	}
-	return result
+	return node.TraverserUtil(traversal, traverserReachProb, opponentReachProb, node.traverserWinUtility(traversal))
 }
 
 //TraverserUtil performs a O(N) terminal utility calculation by calculating the total reach probability
 //for the opponent at this node and the card removal probability for each card in a single pass
 //This information is then used to update the EV of each of our hands
+//The card removal is accumulated per card index so the loops only index into arrays
 func (node *TerminalNode) TraverserUtil(traversal *Traversal, travProb, oppProb []float64, utility float64) []float64 {
-	cardRemoval := make(map[poker.Card]float64)
-	utilities := make([]float64, len(travProb))
-	probabilitySum := 0.0
+	utilities := make([]float64, len(traversal.Ranges[traversal.Traverser]))
+	node.foldUtil(traversal, traversal.Traverser, utilities, oppProb, utility)
+	return utilities
+}
 
-	opponent := traversal.Traverser ^ 1
-	traverserHands := traversal.GetRange(traversal.Traverser)
-	oopHands := traversal.GetRange(opponent)
+//foldUtil writes the utility of every active hand of traverser to utilities, which must be zeroed, only the
+//active opponent hands can have reach
+func (node *TerminalNode) foldUtil(traversal *Traversal, traverser int, utilities, oppProb []float64, utility float64) {
+	opponentReachUtility(traversal, traverser, node.board, utilities, oppProb, utility)
+}
 
-	for index := range oopHands {
-		cardRemoval[oopHands[index].Hand[0]] += oppProb[index]
-		cardRemoval[oopHands[index].Hand[1]] += oppProb[index]
+//opponentReachUtility adds the reach of the opponent hands not overlapping each active hand of traverser times
+//utility to utilities. Hands overlapping board get nothing
+func opponentReachUtility(traversal *Traversal, traverser int, board []poker.Card, utilities, oppProb []float64,
+	utility float64) {
+	var cardRemoval [52]float64
+	opponent := traverser ^ 1
+	probabilitySum := 0.0
+
+	oppCards := traversal.cardIndexes[opponent]
+	for _, index := range traversal.activeHands(opponent) {
+		cardRemoval[oppCards[index][0]] += oppProb[index]
+		cardRemoval[oppCards[index][1]] += oppProb[index]
 		probabilitySum += oppProb[index]
 	}
 
-	for index := range traverserHands {
-		if CheckHandBoardOverlap(traverserHands[index].Hand, node.board) {
+	mask := boardMask(board)
+	travCards := traversal.cardIndexes[traverser]
+	oppIndexes := traversal.IndexCaches[opponent]
+	for _, index := range traversal.activeHands(traverser) {
+		cards := travCards[index]
+		if mask&(1<<uint(cards[0])|1<<uint(cards[1])) != 0 {
 			continue
 		}
-		var removal float64
-		sameHandIndex, ok := traversal.IndexCaches[opponent][traverserHands[index].Hand]
-		if ok {
-			removal = -cardRemoval[traverserHands[index].Hand[0]] - cardRemoval[traverserHands[index].Hand[1]] +
-				      oppProb[sameHandIndex]
-		} else {
-			removal = -cardRemoval[traverserHands[index].Hand[0]] - cardRemoval[traverserHands[index].Hand[1]]
+		removal := -cardRemoval[cards[0]] - cardRemoval[cards[1]]
+		if sameHandIndex := oppIndexes[traversal.comboIndexes[traverser][index]]; sameHandIndex >= 0 {
+			removal += oppProb[sameHandIndex]
 		}
-		utilities[index] = (probabilitySum + removal) * utility
+		utilities[index] += (probabilitySum + removal) * utility
 	}
-	return utilities
 }
 
 //TraverserUtilSlow this is an O(n^2) utility calculation, used for the naive comparison of the O(n) algorithm
@@ -92,41 +127,7 @@ func (node *TerminalNode) TraverserUtilSlow(travProb, oppProb Range, utility flo
 }
 
 func (node *TerminalNode) BestResponse(traversal *Traversal, opponentReachProb []float64) []float64 {
-	var utility float64
-	if traversal.Traverser == node.playerNode {
-		utility = node.winUtility
-	} else {
-		utility = -node.winUtility
-	}
-	cardRemoval := make(map[poker.Card]float64)
-	utilities := make([]float64, len(traversal.Ranges[traversal.Traverser]))
-	probabilitySum := 0.0
-
-	opponent := traversal.Traverser ^ 1
-	traverserHands := traversal.GetRange(traversal.Traverser)
-	oopHands := traversal.GetRange(opponent)
-
-	for index := range oopHands {
-		cardRemoval[oopHands[index].Hand[0]] += opponentReachProb[index]
-		cardRemoval[oopHands[index].Hand[1]] += opponentReachProb[index]
-		probabilitySum += opponentReachProb[index]
-	}
-
-	for index := range traverserHands {
-		if CheckHandBoardOverlap(traverserHands[index].Hand, node.board) {
-			continue
-		}
-		var removal float64
-		sameHandIndex, ok := traversal.IndexCaches[opponent][traverserHands[index].Hand]
-		if ok {
-			removal = -cardRemoval[traverserHands[index].Hand[0]] - cardRemoval[traverserHands[index].Hand[1]] +
-				opponentReachProb[sameHandIndex]
-		} else {
-			removal = -cardRemoval[traverserHands[index].Hand[0]] - cardRemoval[traverserHands[index].Hand[1]]
-		}
-		utilities[index] = (probabilitySum + removal) * utility
-	}
-	return utilities
+	return node.TraverserUtil(traversal, nil, opponentReachProb, node.traverserWinUtility(traversal))
 }
 
 func (node *TerminalNode) PrintNodeDetails(level int) {
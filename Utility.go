@@ -3,6 +3,7 @@ package solv
 import (
 	"github.com/chehsunliu/poker"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -21,37 +22,31 @@ func cardTo52Int(c poker.Card) int {
 	return int( 4 * rank + suit)
 }
 
-func intToCard(i int) poker.Card {
-	suit := i % 4
-	rank := i / 4
-	return poker.NewCard(string(ranks[rank]) + string(suits[suit]))
-}
+//deck holds every card at its card index so intToCard does not parse card strings
+var deck = newDeck()
 
-func constructPossibleNextCards(board []poker.Card, numNext int) []poker.Card {
-	next := make([]poker.Card, numNext)
-	count := 0
-	for rankIndex := range ranks {
-		for suitIndex := range suits {
-			card := poker.NewCard(string(ranks[rankIndex]) + string(suits[suitIndex]))
-			if !checkCardBoardOverlap(card, board) {
-				next[count] = card
-				count++
-			}
-		}
+func newDeck() [52]poker.Card {
+	var cards [52]poker.Card
+	for i := range cards {
+		cards[i] = poker.NewCard(string(ranks[i/4]) + string(suits[i%4]))
 	}
-	return next
+	return cards
+}
+
+func intToCard(i int) poker.Card {
+	return deck[i]
 }
 
-func constructPossibleRunouts(board []poker.Card, cache *RiverEvaluationCache) [][]poker.Card {
+//constructPossibleRunouts returns every river board of a flop or turn board dealt from the deck of variant
+func constructPossibleRunouts(board []poker.Card, variant Variant) [][]poker.Card {
+	cards := variant.cards()
 	runouts := make([][]poker.Card, 0, 10)
 	if len(board) == 3 {
-		for i := 0; i < 52; i++ {
-			card := intToCard(i)
+		for i, card := range cards {
 			if checkCardBoardOverlap(card, board) {
 				continue
 			}
-			for j := i+1; j < 52; j++ {
-				riverCard := intToCard(j)
+			for _, riverCard := range cards[i+1:] {
 				if checkCardBoardOverlap(riverCard, board) {
 					continue
 				}
@@ -64,8 +59,7 @@ func constructPossibleRunouts(board []poker.Card, cache *RiverEvaluationCache) [
 
 		}
 	} else if len(board) == 4 {
-		for i := 0; i < 52; i++ {
-			card := intToCard(i)
+		for _, card := range cards {
 			if checkCardBoardOverlap(card, board) {
 				continue
 			}
@@ -86,6 +80,15 @@ func convertRangeToFloatSlice(rng Range) []float64 {
 	return arr
 }
 
+//boardMask returns a bit set with the bit of the index of every card of the board set
+func boardMask(board []poker.Card) uint64 {
+	var mask uint64
+	for _, card := range board {
+		mask |= 1 << uint(cardTo52Int(card))
+	}
+	return mask
+}
+
 func checkCardBoardOverlap(card poker.Card, board []poker.Card) bool {
 	for index := range board {
 		if board[index] == card {
@@ -158,6 +161,8 @@ func UnblockedHands(rng, oop Range) []float64 {
 	return handCounts
 }
 
+//HandsStringToHandRange parses a range string, the hands are ordered by their ComboIndex so parsing the same
+//string always gives the same range and the same solve
 func HandsStringToHandRange(hands string) Range {
 	percentageSplitter := regexp.MustCompile(`/\d+?\.\d+?/`)
 	handRange := make(HandToFloatMap)
@@ -176,6 +181,9 @@ func HandsStringToHandRange(hands string) Range {
 		toReturn[count] = *handCombo
 		count++
 	}
+	sort.Slice(toReturn, func(i, j int) bool {
+		return toReturn[i].Hand.ComboIndex() < toReturn[j].Hand.ComboIndex()
+	})
 	return toReturn
 }
 
@@ -211,7 +219,9 @@ func processHandPlus(base string, percentage float64, handRange HandToFloatMap)
 	suited := strings.Contains(base, "s")
 	offsuit := strings.Contains(base, "o")
 	startIndex := strings.Index(ranks, string(base[1]))
-	for i := startIndex; i < len(ranks); i++ {
+	//the kicker goes up to one below the first card, A2s+ ends at AKs
+	endIndex := strings.Index(ranks, string(base[0]))
+	for i := startIndex; i < endIndex; i++ {
 		currentBase := string(base[0]) + string(ranks[i])
 		if !suited && !offsuit {
 			processHandBoth(currentBase, percentage, handRange)
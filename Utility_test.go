@@ -37,7 +37,7 @@ func TestCheckCardBoardOverlap(t *testing.T) {
 func TestConstructPossibleNextCards(t *testing.T) {
 	board := []poker.Card{poker.NewCard("Ac"), poker.NewCard("7s"), poker.NewCard("5s"),
 		poker.NewCard("3d")}
-	ans := constructPossibleNextCards(board, 48)
+	ans := Holdem.nextCards(board)
 	assert.True(t, len(ans) == 48)
 	for index := range ans {
 		assert.True(t, ans[index] > 0)
@@ -69,4 +69,33 @@ func TestIntToCard(t *testing.T) {
 	assert.Equal(t, c3, intToCard(36))
 	assert.Equal(t, c4, intToCard(33))
 	assert.Equal(t, c5, intToCard(22))
-}
\ No newline at end of file
+}
+func TestComboIndex(t *testing.T) {
+	seen := make([]bool, NumCombos)
+	for first := 0; first < 52; first++ {
+		for second := 0; second < first; second++ {
+			hand := Hand{intToCard(first), intToCard(second)}
+			index := hand.ComboIndex()
+			assert.False(t, seen[index])
+			seen[index] = true
+			assert.Equal(t, index, Hand{hand[1], hand[0]}.ComboIndex())
+			assert.Equal(t, [2]int{first, second}, hand.CardIndexes())
+		}
+	}
+	assert.Equal(t, 0, NewHand("2h", "2s").ComboIndex())
+	assert.Equal(t, NumCombos-1, NewHand("Ac", "Ad").ComboIndex())
+
+	rng := HandsStringToHandRange("AKs, 22")
+	indexes := rng.ComboIndexes()
+	for index := range rng {
+		assert.Equal(t, index, indexes[rng[index].Hand.ComboIndex()])
+	}
+	assert.Equal(t, -1, indexes[NewHand("Ks", "Qs").ComboIndex()])
+}
+
+func TestHandsStringToHandRangePlus(t *testing.T) {
+	//the kicker stops below the first card, so no pairs are added
+	assert.Equal(t, 12*4, len(HandsStringToHandRange("A2s+")))
+	assert.Equal(t, 3*12, len(HandsStringToHandRange("AJo+")))
+	assert.Equal(t, 2*16, len(HandsStringToHandRange("KQ+, QJ+")))
+}
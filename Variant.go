@@ -0,0 +1,102 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+)
+
+//Variant is the deck and hand ranking of a hold'em game. Cards keep their index in the 52 card deck in every
+//variant, a variant with a smaller deck never deals the cards it leaves out and ranges lose the hands holding them
+type Variant int
+
+const (
+	//Holdem is played with the full deck and the standard hand ranking
+	Holdem Variant = iota
+	//ShortDeck is played without the twos to fives and ranks hands like NewShortDeckEvaluator
+	ShortDeck
+)
+
+func (variant Variant) String() string {
+	if variant == ShortDeck {
+		return "short deck"
+	}
+	return "holdem"
+}
+
+//lowestRank returns the rank of the lowest card of the deck, 0 for a two
+func (variant Variant) lowestRank() int {
+	if variant == ShortDeck {
+		return 4
+	}
+	return 0
+}
+
+//cards returns the cards of the deck by card index, it must not be modified
+func (variant Variant) cards() []poker.Card {
+	return deck[4*variant.lowestRank():]
+}
+
+//Deck returns the cards of the deck, lowest rank first
+func (variant Variant) Deck() []poker.Card {
+	return append([]poker.Card(nil), variant.cards()...)
+}
+
+//DeckSize returns the number of cards of the deck
+func (variant Variant) DeckSize() int {
+	return len(variant.cards())
+}
+
+//InDeck returns if the deck holds card
+func (variant Variant) InDeck(card poker.Card) bool {
+	return int(card.Rank()) >= variant.lowestRank()
+}
+
+//rules returns the rules the variant ranks five card hands by
+func (variant Variant) rules() handRules {
+	if variant == ShortDeck {
+		return shortDeckRules
+	}
+	return holdemRules
+}
+
+//Evaluator returns the shared evaluator ranking the hands of the variant
+func (variant Variant) Evaluator() Evaluator {
+	if variant == ShortDeck {
+		return DefaultShortDeckEvaluator()
+	}
+	return DefaultEvaluator()
+}
+
+//HandRange parses a range string like HandsStringToHandRange and leaves out the hands the deck does not hold, so
+//22+ is every pair from sixes up in short deck
+func (variant Variant) HandRange(hands string) Range {
+	handRange := HandsStringToHandRange(hands)
+	inDeck := make(Range, 0, len(handRange))
+	for _, combo := range handRange {
+		if variant.InDeck(combo.Hand[0]) && variant.InDeck(combo.Hand[1]) {
+			inDeck = append(inDeck, combo)
+		}
+	}
+	return inDeck
+}
+
+//nextCards returns the cards that can be dealt on board in card index order
+func (variant Variant) nextCards(board []poker.Card) []poker.Card {
+	cards := variant.cards()
+	next := make([]poker.Card, 0, len(cards)-len(board))
+	for _, card := range cards {
+		if !checkCardBoardOverlap(card, board) {
+			next = append(next, card)
+		}
+	}
+	return next
+}
+
+//pairRunouts returns the number of runouts of a board with boardCards cards that a pair of hands not overlapping
+//each other or the board sees, the runouts leave out the four cards of the hands
+func (variant Variant) pairRunouts(boardCards int) int {
+	cards := variant.DeckSize() - boardCards - 4
+	if boardCards == 3 {
+		return cards * (cards - 1) / 2
+	}
+	return cards
+}
@@ -0,0 +1,105 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+var shortDeckTurn = []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("7s"), poker.NewCard("6c")}
+
+func TestVariantDeck(t *testing.T) {
+	assert.Equal(t, 52, Holdem.DeckSize())
+	assert.Equal(t, 36, ShortDeck.DeckSize())
+	assert.Equal(t, poker.NewCard("6s"), ShortDeck.Deck()[0])
+	assert.False(t, ShortDeck.InDeck(poker.NewCard("5c")))
+	assert.True(t, ShortDeck.InDeck(poker.NewCard("6c")))
+	assert.Len(t, ShortDeck.nextCards(shortDeckTurn), 32)
+	assert.Len(t, constructPossibleRunouts(shortDeckTurn[:3], ShortDeck), 33*32/2)
+	//a pair of hands sees the runouts without its four cards
+	assert.Equal(t, 44, Holdem.pairRunouts(4))
+	assert.Equal(t, 28, ShortDeck.pairRunouts(4))
+	assert.Equal(t, 29*28/2, ShortDeck.pairRunouts(3))
+}
+
+func TestVariantHandRange(t *testing.T) {
+	assert.Len(t, ShortDeck.HandRange("22+"), 9*6)
+	assert.Len(t, ShortDeck.HandRange("A2s+"), 8*4)
+	assert.Len(t, ShortDeck.HandRange("54s, 65s"), 0)
+	assert.Equal(t, HandsStringToHandRange("AA, KQs"), Holdem.HandRange("AA, KQs"))
+	for _, combo := range ShortDeck.HandRange("/50.0/22+, AK") {
+		assert.True(t, ShortDeck.InDeck(combo.Hand[0]) && ShortDeck.InDeck(combo.Hand[1]))
+	}
+}
+
+func TestShortDeckChanceNodeMatchesAllInEquity(t *testing.T) {
+	oop := RemoveConflicts(ShortDeck.HandRange("AA, KQ, 99, 87s, 76s, JTs"), shortDeckTurn)
+	ip := RemoveConflicts(ShortDeck.HandRange("KK, AK, T9s, 66, A6s, QJ"), shortDeckTurn)
+	cache := NewRiverEvaluationCache(oop, ip)
+	cache.variant = ShortDeck
+	cache.ranks = NewRankCache(ShortDeck.Evaluator())
+	//dealing the river to a showdown of the pot averages the rivers like the equity of an all in
+	chance := NewChanceNode(100, 0, shortDeckTurn, 2, ShortDeck)
+	assert.Len(t, chance.nextCards, 32)
+	for _, card := range chance.nextCards {
+		river := append(append([]poker.Card(nil), shortDeckTurn...), card)
+		showdown := NewShowdownNode(100, 1, river, cache)
+		showdown.cacheIndex = cache.InsertBoard(river)
+		chance.AddNextNode(showdown)
+	}
+	allIn := NewAllInShowdownNode(100, 2, cache)
+	allIn.table = cache.EquityTable(shortDeckTurn)
+	traversal := NewTraversal(oop, ip)
+	random := rand.New(rand.NewSource(3))
+	for traverser := range traversal.Ranges {
+		traversal.Traverser = traverser
+		opponentReach := randomReach(len(traversal.Ranges[traverser^1]), random)
+		assert.InDeltaSlice(t, allIn.BestResponse(traversal, opponentReach), chance.BestResponse(traversal, opponentReach),
+			1e-9)
+	}
+}
+
+func TestShortDeckTree(t *testing.T) {
+	oop := RemoveConflicts(ShortDeck.HandRange("TT+, AQs+, KQs, AK, 98s, 76s"), shortDeckTurn)
+	ip := RemoveConflicts(ShortDeck.HandRange("99+, ATs+, KTs+, QJs, AQ+, A6s"), shortDeckTurn)
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetVariant(ShortDeck)
+	root := mustConstructTree(100, 150, params, ip, oop, shortDeckTurn)
+	traversal := NewTraversal(oop, ip)
+	_, _, start := Exploitability(traversal, root)
+	runIterations(traversal, 200, root)
+	_, _, exploitability := Exploitability(traversal, root)
+	assert.Less(t, exploitability, start/20)
+	assert.Greater(t, exploitability, 0.0)
+}
+
+func TestConstructTreeChecksVariant(t *testing.T) {
+	oop := RemoveConflicts(ShortDeck.HandRange("TT+, AQs+"), shortDeckTurn)
+	ip := RemoveConflicts(ShortDeck.HandRange("99+, ATs+"), shortDeckTurn)
+	params := NewConstructionParams(0.75, 1.2)
+	params.SetVariant(ShortDeck)
+	_, err := ConstructTree(100, 150, params, ip, oop, shortDeckTurn)
+	assert.Nil(t, err)
+
+	//hands and board cards below six are not in the short deck
+	_, err = ConstructTree(100, 150, params, ip, append(oop, *NewCombo(NewHand("5h", "5d"), 1)), shortDeckTurn)
+	assert.NotNil(t, err)
+	board := []poker.Card{shortDeckTurn[0], shortDeckTurn[1], shortDeckTurn[2], poker.NewCard("2c")}
+	_, err = ConstructTree(100, 150, params, ip, oop, board)
+	assert.NotNil(t, err)
+
+	//showdowns have to be ranked by the short deck rules
+	params.SetEvaluator(DefaultEvaluator())
+	_, err = ConstructTree(100, 150, params, ip, oop, shortDeckTurn)
+	assert.NotNil(t, err)
+	params.SetEvaluator(NewShortDeckEvaluator())
+	_, err = ConstructTree(100, 150, params, ip, oop, shortDeckTurn)
+	assert.Nil(t, err)
+	params.SetRankCache(NewRankCache(nil))
+	_, err = ConstructTree(100, 150, params, ip, oop, shortDeckTurn)
+	assert.NotNil(t, err)
+	params.SetRankCache(NewRankCache(DefaultShortDeckEvaluator()))
+	_, err = ConstructTree(100, 150, params, ip, oop, shortDeckTurn)
+	assert.Nil(t, err)
+}
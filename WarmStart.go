@@ -0,0 +1,150 @@
+package solv
+
+import "math"
+
+const (
+	actionFold = iota
+	actionPassive
+	actionBet
+	actionAllIn
+)
+
+//WarmStart initializes the regrets and strategy sums of a newly constructed tree from a solved tree of a related
+//configuration, so training only has to adjust the solution instead of starting from zero. Nodes are matched by
+//their action path, each bet of the new tree takes the values of the nearest bet size (as a fraction of the pot)
+//of the solved tree and hands are matched by Hand between the two ranges. A solved bet taken by several new bets
+//has its strategy sums split between them, so its average frequency is not counted once per bet. Hands and nodes
+//without a match start from zero. Training of the new traversal continues the discounting from the iteration after
+//the one the solved one stopped at
+func WarmStart(root *GameNode, traversal *Traversal, solved *GameNode, solvedTraversal *Traversal) {
+	var handMaps [2][]int
+	for player := range handMaps {
+		handMaps[player] = make([]int, len(traversal.Ranges[player]))
+		for hand, combo := range traversal.Ranges[player] {
+			handMaps[player][hand] = solvedTraversal.HandIndex(player, combo.Hand)
+		}
+	}
+	warmStartNode(root, solved, handMaps, identityPermutation)
+	traversal.startIteration = solvedTraversal.Iteration + 1
+}
+
+//warmStartNode copies the solution of solved into current, toSolved maps the cards of current's subtree to the
+//cards of solved's subtree which differ when only one of them is shared by isomorphic cards
+func warmStartNode(current, solved Node, handMaps [2][]int, toSolved suitPermutation) {
+	switch node := current.(type) {
+	case *GameNode:
+		solvedNode, ok := solved.(*GameNode)
+		if !ok || solvedNode.playerNode != node.playerNode {
+			return
+		}
+		actionMap := matchActions(node, solvedNode)
+		//copies[i] is the number of actions of node taking the values of solved action i
+		copies := make([]float64, len(solvedNode.nextNodes))
+		for _, solvedAction := range actionMap {
+			if solvedAction >= 0 {
+				copies[solvedAction]++
+			}
+		}
+		handMap := handMaps[node.playerNode]
+		for hand := 0; hand < node.NumHands(); hand++ {
+			solvedHand := handMap[hand]
+			if solvedHand < 0 {
+				continue
+			}
+			for action, solvedAction := range actionMap {
+				if solvedAction < 0 {
+					continue
+				}
+				node.values.setRegret(hand, action, solvedNode.values.regret(solvedHand, solvedAction))
+				node.values.setStrategySum(hand, action,
+					solvedNode.values.strategySum(solvedHand, solvedAction)/copies[solvedAction])
+			}
+		}
+		for action, solvedAction := range actionMap {
+			if solvedAction >= 0 {
+				warmStartNode(node.nextNodes[action], solvedNode.nextNodes[solvedAction], handMaps, toSolved)
+			}
+		}
+	case *ChanceNode:
+		solvedNode, ok := solved.(*ChanceNode)
+		if !ok {
+			return
+		}
+		for index, card := range node.nextCards {
+			//a subtree of an isomorphic card is in the suits of its canonical card
+			if isomorphism := node.isomorphicCard(index); isomorphism != nil {
+				if node.sharesCanonical(index) {
+					continue
+				}
+				card = node.nextCards[isomorphism.canonical]
+			}
+			solvedIndex := solvedNode.CardIndex(permuteCard(card, toSolved))
+			if solvedIndex < 0 {
+				continue
+			}
+			nextHandMaps := handMaps
+			nextToSolved := toSolved
+			if isomorphism := solvedNode.isomorphicCard(solvedIndex); isomorphism != nil {
+				nextToSolved = toSolved.then(isomorphism.permutation)
+				for player := range handMaps {
+					nextHandMaps[player] = make([]int, len(handMaps[player]))
+					for hand, solvedHand := range handMaps[player] {
+						nextHandMaps[player][hand] = -1
+						if solvedHand >= 0 {
+							nextHandMaps[player][hand] = isomorphism.handPermutations[player][solvedHand]
+						}
+					}
+				}
+			}
+			warmStartNode(node.nextNodes[index], solvedNode.nextNodes[solvedIndex], nextHandMaps, nextToSolved)
+		}
+	}
+}
+
+//matchActions returns the index of the matching action of solved for each action of node, or -1 if there is none.
+//Bets prefer bets and all ins prefer all ins, but either can fall back to the other
+func matchActions(node, solved *GameNode) []int {
+	matches := make([]int, len(node.nextNodes))
+	for action, next := range node.nextNodes {
+		kind, size := classifyAction(node, next)
+		matches[action] = -1
+		bestDistance := math.Inf(1)
+		bestSameKind := false
+		for solvedAction, solvedNext := range solved.nextNodes {
+			solvedKind, solvedSize := classifyAction(solved, solvedNext)
+			isBet := kind == actionBet || kind == actionAllIn
+			isSolvedBet := solvedKind == actionBet || solvedKind == actionAllIn
+			if solvedKind != kind && !(isBet && isSolvedBet) {
+				continue
+			}
+			sameKind := solvedKind == kind
+			distance := math.Abs(size - solvedSize)
+			if (sameKind && !bestSameKind) || (sameKind == bestSameKind && distance < bestDistance) {
+				matches[action] = solvedAction
+				bestDistance = distance
+				bestSameKind = sameKind
+			}
+		}
+	}
+	return matches
+}
+
+//classifyAction returns the kind of action leading from node to next, and for bets the size of the bet, or
+//raise on top of the last bet, as a fraction of the pot
+func classifyAction(node *GameNode, next Node) (int, float64) {
+	switch nextNode := next.(type) {
+	case *TerminalNode:
+		return actionFold, 0
+	case *GameNode:
+		if nextNode.potSize == node.potSize {
+			return actionPassive, 0
+		}
+		lastBet := math.Abs(node.ipPlayerStack - node.oopPlayerStack)
+		size := (nextNode.potSize - node.potSize - lastBet) / (node.potSize + lastBet)
+		if nextNode.ipPlayerStack == 0 || nextNode.oopPlayerStack == 0 {
+			return actionAllIn, size
+		}
+		return actionBet, size
+	}
+	return actionPassive, 0
+}
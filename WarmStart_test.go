@@ -0,0 +1,68 @@
+package solv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newWarmStartTestTree(params *ConstructionParams) (*GameNode, *Traversal) {
+	oop := RemoveConflicts(HandsStringToHandRange("JJ+, 44, A5s, 76s, KQs"), riverBoard)
+	ip := RemoveConflicts(HandsStringToHandRange("QQ+, T9s, 64s, A2s, K5s"), riverBoard)
+	return mustConstructTree(400, 4000, params, ip, oop, riverBoard), NewTraversal(oop, ip)
+}
+
+func TestWarmStart(t *testing.T) {
+	solved, solvedTrav := newWarmStartTestTree(NewConstructionParams(1.0, 1.2))
+	runIterations(solvedTrav, 300, solved)
+	solvedTrav.Iteration = 299
+	_, _, solvedExploitability := Exploitability(solvedTrav, solved)
+
+	//the same configuration continues exactly where the solve stopped
+	same, sameTrav := newWarmStartTestTree(NewConstructionParams(1.0, 1.2))
+	WarmStart(same, sameTrav, solved, solvedTrav)
+	_, _, sameExploitability := Exploitability(sameTrav, same)
+	assert.InDelta(t, solvedExploitability, sameExploitability, 0.0001)
+	//the first warm iteration is the one after the last solved iteration
+	assert.Equal(t, 300, sameTrav.startIteration)
+
+	params := NewConstructionParams(1.0, 1.2)
+	params.SetBets(3, 0, [][]float64{{0.5, 1.0}})
+	params.SetBets(3, 1, [][]float64{{0.5, 1.0}})
+	cold, coldTrav := newWarmStartTestTree(params)
+	warm, warmTrav := newWarmStartTestTree(params)
+	assert.Equal(t, 3, warm.NumActions())
+	WarmStart(warm, warmTrav, solved, solvedTrav)
+
+	//the half pot bet takes the values of the pot sized bet, the nearest size in the solved tree
+	for hand, combo := range warmTrav.Ranges[0] {
+		solvedHand := solvedTrav.HandIndex(0, combo.Hand)
+		assert.Equal(t, solved.Regrets(solvedHand)[1], warm.Regrets(hand)[1])
+		assert.Equal(t, solved.Regrets(solvedHand)[1], warm.Regrets(hand)[2])
+		//the bets split the strategy sum of the pot sized bet, which keeps its average frequency
+		assert.Equal(t, solved.StrategySums(solvedHand)[1]/2, warm.StrategySums(hand)[1])
+		assert.Equal(t, solved.StrategySums(solvedHand)[1]/2, warm.StrategySums(hand)[2])
+		assert.InDelta(t, solved.GetAverageStrategy()[solvedHand][1],
+			warm.GetAverageStrategy()[hand][1]+warm.GetAverageStrategy()[hand][2], 1e-9)
+	}
+
+	runIterations(coldTrav, 20, cold)
+	runIterations(warmTrav, 20, warm)
+	_, _, coldExploitability := Exploitability(coldTrav, cold)
+	_, _, warmExploitability := Exploitability(warmTrav, warm)
+	assert.True(t, warmExploitability < coldExploitability)
+}
+
+func TestMatchActions(t *testing.T) {
+	node := NewGameNode(0, 100, 1000, 1000)
+	node.AddNextNode(NewGameNode(1, 100, 1000, 1000))
+	node.AddNextNode(NewGameNode(1, 150, 1000, 950))
+	node.AddNextNode(NewGameNode(1, 1100, 1000, 0))
+	solved := NewGameNode(0, 100, 1000, 1000)
+	solved.AddNextNode(NewGameNode(1, 100, 1000, 1000))
+	solved.AddNextNode(NewGameNode(1, 175, 1000, 925))
+	solved.AddNextNode(NewGameNode(1, 200, 1000, 900))
+
+	assert.Equal(t, []int{0, 1, 2}, matchActions(node, solved))
+	//bets keep matching bets before falling back to the all in
+	assert.Equal(t, []int{0, 1, 1}, matchActions(solved, node))
+}
@@ -0,0 +1,130 @@
+package solv
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+//WorkerPool runs the subtrees of the cards dealt at chance nodes and of the actions of game nodes on a fixed
+//number of goroutines, and builds the subtrees of dealt cards while a tree is constructed. A subtree is only
+//handed to a worker that is idle at that moment, otherwise the traversing goroutine traverses it itself, so work
+//is spread at the shallowest nodes first and nested nodes only go parallel once workers run out of work above
+//them. The traversing goroutine counts as one of the workers, so a pool with
+//one worker traverses serially. Several traversals can share one pool so solves running at the same time only
+//add their own traversing goroutines to the workers of the pool
+type WorkerPool struct {
+	workers int
+	idle    int32
+	tasks   chan subtreeTask
+}
+
+//subtreeTask is a card, a group of isomorphic cards or the cards of a bucket of a chance node, or an action of a
+//game node, waiting to be traversed. Action tasks are given a forked traversal since the traversal of the game
+//node keeps being used. Construction tasks build or initialize the subtree of a dealt card while a tree is
+//constructed
+type subtreeTask struct {
+	construct    func()
+	chance       *ChanceNode
+	game         *GameNode
+	traversal    *Traversal
+	worker       *Traversal
+	index        int
+	group        *isomorphGroup
+	cards        []int
+	travReach    []float64
+	oppReach     []float64
+	subResults   [][]float64
+	bestResponse bool
+	wg           *sync.WaitGroup
+}
+
+var defaultPool *WorkerPool
+var defaultPoolOnce sync.Once
+
+//DefaultWorkerPool returns the pool shared by every traversal that is not given its own, it has one worker per
+//logical core as set by GOMAXPROCS when it is first used
+func DefaultWorkerPool() *WorkerPool {
+	defaultPoolOnce.Do(func() {
+		defaultPool = NewWorkerPool(0)
+	})
+	return defaultPool
+}
+
+//NewWorkerPool starts a pool with the given number of workers, or GOMAXPROCS workers if workers is not positive
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	pool := &WorkerPool{
+		workers: workers,
+		tasks:   make(chan subtreeTask),
+	}
+	for i := 1; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+//Workers returns the number of workers of the pool
+func (pool *WorkerPool) Workers() int {
+	return pool.workers
+}
+
+//Close stops the workers once they are idle, the pool must not be used by any traversal afterwards
+func (pool *WorkerPool) Close() {
+	close(pool.tasks)
+}
+
+func (pool *WorkerPool) work() {
+	for {
+		atomic.AddInt32(&pool.idle, 1)
+		task, ok := <-pool.tasks
+		atomic.AddInt32(&pool.idle, -1)
+		if !ok {
+			return
+		}
+		task.run()
+	}
+}
+
+//hasIdleWorker reports whether a worker might take a task, so callers can skip preparing tasks no one would take
+func (pool *WorkerPool) hasIdleWorker() bool {
+	return atomic.LoadInt32(&pool.idle) > 0
+}
+
+//tryRun hands the task to an idle worker and reports whether one took it
+func (pool *WorkerPool) tryRun(task subtreeTask) bool {
+	select {
+	case pool.tasks <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+//run hands the task to an idle worker, or runs it on the calling goroutine if every worker is busy
+func (pool *WorkerPool) run(task subtreeTask) {
+	if !pool.hasIdleWorker() || !pool.tryRun(task) {
+		task.run()
+	}
+}
+
+func (task *subtreeTask) run() {
+	switch {
+	case task.construct != nil:
+		task.construct()
+		task.wg.Done()
+	case task.game != nil:
+		task.game.traverseAction(task.traversal, task.worker, task.index, task.travReach, task.oppReach,
+			task.subResults, task.wg)
+	case task.cards != nil:
+		task.chance.dealBucket(task.traversal, task.cards, task.travReach, task.oppReach, task.subResults, task.wg)
+	case task.group != nil:
+		task.chance.dealIsomorphicCards(task.traversal, task.group, task.travReach, task.oppReach, task.subResults,
+			task.bestResponse, task.wg)
+	default:
+		task.chance.dealCard(task.traversal, task.index, task.travReach, task.oppReach, task.subResults,
+			task.bestResponse, task.wg)
+	}
+}
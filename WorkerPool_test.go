@@ -0,0 +1,160 @@
+package solv
+
+import (
+	"github.com/chehsunliu/poker"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestWorkerPoolMatchesSerialTraversal(t *testing.T) {
+	serialRoot, serialTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	serialPool := NewWorkerPool(1)
+	defer serialPool.Close()
+	serialTrav.SetWorkerPool(serialPool)
+	runIterations(serialTrav, 3, serialRoot)
+
+	parallelRoot, parallelTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	parallelPool := NewWorkerPool(4)
+	defer parallelPool.Close()
+	parallelTrav.SetWorkerPool(parallelPool)
+	runIterations(parallelTrav, 3, parallelRoot)
+
+	//every card writes its own result and the results are summed in card order, so the pool does not change them
+	assertSameRootRegrets(t, serialRoot, serialTrav, parallelRoot, parallelTrav)
+	_, _, serialExploitability := Exploitability(serialTrav, serialRoot)
+	_, _, parallelExploitability := Exploitability(parallelTrav, parallelRoot)
+	assert.InDelta(t, serialExploitability, parallelExploitability, 1e-9)
+}
+
+//assertSameRootRegrets compares the regrets of the root by hand, ranges parsed from strings are not ordered so
+//the sums over hands may only agree up to rounding
+func assertSameRootRegrets(t *testing.T, expectedRoot *GameNode, expectedTrav *Traversal, root *GameNode,
+	traversal *Traversal) {
+	for index := range expectedTrav.Ranges[0] {
+		hand := traversal.HandIndex(0, expectedTrav.Ranges[0][index].Hand)
+		assert.InDeltaSlice(t, expectedRoot.Regrets(index), root.Regrets(hand), 1e-9)
+	}
+}
+
+func TestParallelRiverMatchesSerial(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s"), poker.NewCard("2c"), poker.NewCard("Ts")}
+	oop := RemoveConflicts(HandsStringToHandRange("22+, A2s+, K9s+, QTs+, JTs, ATo+, KJo+"), board)
+	ip := RemoveConflicts(HandsStringToHandRange("22+, A2s+, K2s+, Q8s+, J8s+, T8s+, 97s+, 87s, A8o+, KTo+, QTo+"), board)
+	trees := make([]*GameNode, 2)
+	for i, workers := range []int{1, 4} {
+		trees[i] = mustConstructTree(100, 400, NewConstructionParams(0.75, 1.2), ip, oop, board)
+		traversal := NewTraversal(oop, ip)
+		pool := NewWorkerPool(workers)
+		traversal.SetWorkerPool(pool)
+		runIterations(traversal, 20, trees[i])
+		pool.Close()
+	}
+	//the ranges are shared so the trees index hands the same way and the results must match exactly
+	for hand := 0; hand < trees[0].NumHands(); hand++ {
+		assert.Equal(t, trees[0].Regrets(hand), trees[1].Regrets(hand))
+		assert.Equal(t, trees[0].StrategySums(hand), trees[1].StrategySums(hand))
+	}
+}
+
+func TestWorkerPoolSharedBetweenSolves(t *testing.T) {
+	expectedRoot, expectedTrav := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	expectedTrav.SetWorkerPool(NewWorkerPool(1))
+	runIterations(expectedTrav, 2, expectedRoot)
+
+	pool := NewWorkerPool(3)
+	defer pool.Close()
+	roots := make([]*GameNode, 3)
+	traversals := make([]*Traversal, 3)
+	var wg sync.WaitGroup
+	for solve := range roots {
+		root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+		traversal.SetWorkerPool(pool)
+		roots[solve], traversals[solve] = root, traversal
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runIterations(traversal, 2, root)
+		}()
+	}
+	wg.Wait()
+	for solve := range roots {
+		assertSameRootRegrets(t, expectedRoot, expectedTrav, roots[solve], traversals[solve])
+	}
+}
+
+func TestWorkerPoolWorkers(t *testing.T) {
+	pool := NewWorkerPool(4)
+	assert.Equal(t, 4, pool.Workers())
+	pool.Close()
+	pool = NewWorkerPool(0)
+	assert.Equal(t, runtime.GOMAXPROCS(0), pool.Workers())
+	pool.Close()
+}
+
+func BenchmarkCFRIterationTurnSerial(b *testing.B) {
+	root, traversal := newTurnTestTree(NewConstructionParams(0.75, 1.2))
+	traversal.SetWorkerPool(NewWorkerPool(1))
+	benchmarkCFRIteration(b, root, traversal)
+}
+
+//assertSameTree checks two trees have the same nodes in the same order with the same rankings and equity tables
+func assertSameTree(t *testing.T, expected, actual Node) {
+	switch node := expected.(type) {
+	case *GameNode:
+		other := actual.(*GameNode)
+		assert.Equal(t, node.potSize, other.potSize)
+		assert.Equal(t, node.playerNode, other.playerNode)
+		assert.Equal(t, node.NumHands(), other.NumHands())
+		assert.Equal(t, len(node.nextNodes), len(other.nextNodes))
+		for index := range node.nextNodes {
+			assertSameTree(t, node.nextNodes[index], other.nextNodes[index])
+		}
+	case *ChanceNode:
+		other := actual.(*ChanceNode)
+		assert.Equal(t, node.nextCards, other.nextCards)
+		for index := range node.nextNodes {
+			if node.isomorphicCard(index) != nil {
+				assert.Same(t, other.nextNodes[other.isomorphicCard(index).canonical], other.nextNodes[index])
+				continue
+			}
+			assertSameTree(t, node.nextNodes[index], other.nextNodes[index])
+		}
+	case *AllInShowdownNode:
+		other := actual.(*AllInShowdownNode)
+		assert.Equal(t, node.table, other.table)
+		assert.Equal(t, len(node.nextNodes), len(other.nextNodes))
+		for index := range node.nextNodes {
+			assertSameTree(t, node.nextNodes[index], other.nextNodes[index])
+		}
+	case *ShowdownNode:
+		other := actual.(*ShowdownNode)
+		assert.Equal(t, node.cacheIndex, other.cacheIndex)
+		assert.Equal(t, node.cache.RankingCache[node.cacheIndex], other.cache.RankingCache[other.cacheIndex])
+	case *TerminalNode:
+		other := actual.(*TerminalNode)
+		assert.Equal(t, node.winUtility, other.winUtility)
+		assert.Equal(t, node.board, other.board)
+	}
+}
+
+func TestParallelConstructionIsDeterministic(t *testing.T) {
+	board := []poker.Card{poker.NewCard("Kd"), poker.NewCard("9h"), poker.NewCard("4s")}
+	oop := RemoveConflicts(HandsStringToHandRange("TT+, AQs+, KQs, AK"), board)
+	ip := RemoveConflicts(HandsStringToHandRange("99+, ATs+, KTs+, QJs, AQ+"), board)
+	trees := make([]*GameNode, 0, 3)
+	for _, workers := range []int{1, 4, 8} {
+		params := NewConstructionParams(1.0, 1.2)
+		//runout showdowns insert every river board into the rank cache from many goroutines
+		params.SetEquityTables(false)
+		pool := NewWorkerPool(workers)
+		params.SetWorkerPool(pool)
+		boardCopy := make([]poker.Card, len(board))
+		copy(boardCopy, board)
+		trees = append(trees, mustConstructTree(100, 150, params, ip, oop, boardCopy))
+		pool.Close()
+	}
+	assertSameTree(t, trees[0], trees[1])
+	assertSameTree(t, trees[0], trees[2])
+}
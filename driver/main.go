@@ -10,6 +10,14 @@ import (
 )
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+var threads = flag.Int("threads", 0, "number of subtrees traversed at once, 0 uses every core")
+var prune = flag.Bool("prune", false, "skip the subtrees of actions with a large negative regret")
+var simultaneous = flag.Bool("simultaneous", false, "update both players in a single walk per iteration")
+var samples = flag.Int("samples", 0, "cards dealt per chance node each iteration, 0 deals every card")
+var seed = flag.Int64("seed", 1, "seed of the sampled cards")
+var rake = flag.Float64("rake", 0, "percent of every pot taken as rake")
+var rakeCap = flag.Float64("rakecap", 0, "most chips taken as rake from a pot, 0 for no cap")
+var shortDeck = flag.Bool("shortdeck", false, "play short deck hold'em without the twos to fives")
 
 func main() {
 	
@@ -41,16 +49,33 @@ func main() {
 	//ipHands := "QQ, 99"
 
 
-	oop := solv.HandsStringToHandRange(oopHands)
-	ip := solv.HandsStringToHandRange(ipHands)
+	variant := solv.Holdem
+	if *shortDeck {
+		variant = solv.ShortDeck
+		//the five of the board is not in the short deck
+		board[2] = poker.NewCard("6s")
+	}
+	oop := variant.HandRange(oopHands)
+	ip := variant.HandRange(ipHands)
 	oop = solv.RemoveConflicts(oop, board)
 	ip = solv.RemoveConflicts(ip, board)
 
 
 	params := solv.NewConstructionParams(1.0, 1.2)
-	tree := solv.ConstructTree(400, 4000, params, ip, oop, board)
+	params.SetRake(solv.Rake{Percent: *rake, Cap: *rakeCap})
+	params.SetVariant(variant)
+	tree, err := solv.ConstructTree(400, 4000, params, ip, oop, board)
+	if err != nil {
+		log.Fatal(err)
+	}
 	solv.OutputTree(tree)
 	traversal := solv.NewTraversal(oop, ip)
+	if *threads > 0 {
+		traversal.SetWorkerPool(solv.NewWorkerPool(*threads))
+	}
+	traversal.SetPruning(*prune)
+	traversal.SetSimultaneous(*simultaneous)
+	traversal.SetChanceSampling(*samples, *seed)
 	//the result should be -0.9 +0.9 for the suited game
 	solv.Train(traversal, 1000, tree)
 